@@ -33,7 +33,14 @@ type DB interface {
 	// m := g.DB().Table("user")
 	//
 	// m := g.DB().Model("user")
-	Table(table ...string) *Model
+	//
+	// 参数<tableNameOrSubQuery>也可以是*Model对象，此时将其作为子查询:
+	//
+	// m := g.DB().Model(g.DB().Model("user").Where("status", 1)).As("u")
+	//
+	// 注意参数类型为...interface{}，展开[]string类型参数的调用如Table(names...)，
+	// 需要改为Table(gconv.Interfaces(names)...)，Model方法相同。
+	Table(tableNameOrSubQuery ...interface{}) *Model
 	// 用于创建指定数据表的Model对象。
 	//
 	// 示例:
@@ -46,7 +53,15 @@ type DB interface {
 	//
 	// 等价于
 	// m := g.DB("user-center").Model("user")
-	Model(table ...string) *Model
+	Model(tableNameOrSubQuery ...interface{}) *Model
+	// 使用“UNION”组合多个模型的查询结果，返回的Model对象可以继续进行排序、分页、计数等操作。
+	//
+	// 示例:
+	//
+	// db.Union(db.Model("user").Where("id", 1), db.Model("user").Where("id", 2)).Order("id desc").All()
+	Union(unions ...*Model) *Model
+	// 使用“UNION ALL”组合多个模型的查询结果，不去除重复的记录，其他同Union。
+	UnionAll(unions ...*Model) *Model
 	// Schema返回一个模式对象,用于切换数据库。
 	Schema(schema string) *Schema
 
@@ -211,8 +226,12 @@ type DB interface {
 
 	// 开启事务操作
 	Begin() (*TX, error)
+	// 使用指定的上下文和选项(隔离级别、只读、超时时间)开启事务操作。
+	BeginWithOptions(ctx context.Context, options TxOptions) (*TX, error)
 	// 事务的闭包操作，输入参数只有一个函数。
 	Transaction(f func(tx *TX) error) (err error)
+	// 使用指定的上下文和选项(隔离级别、只读、超时时间)进行事务的闭包操作。
+	TransactionWithOptions(ctx context.Context, options TxOptions, f func(tx *TX) error) (err error)
 
 	//
 	GetCache() *gcache.Cache
@@ -248,6 +267,34 @@ type DB interface {
 	HasTable(name string) (bool, error)
 	// 过滤数据库连接信息。
 	FilteredLinkInfo() string
+	// 返回指定保存点操作<operation>的sql语句，参数<name>为已转义的保存点名称。
+	//
+	// 如果当前数据库不支持该操作，则返回空字符串。
+	GetSavePointSql(operation int, name string) string
+	// 使用<unionType>（UNION/UNION ALL）组合多个查询语句<sqlList>，返回组合后的sql语句。
+	//
+	// 自定义驱动可以覆盖该方法以支持不同数据库的组合查询语法。
+	GetUnionSql(unionType string, sqlList []string) string
+	// 返回公共表表达式(CTE)语句的起始关键字，参数<recursive>指定是否包含递归的公共表表达式。
+	//
+	// 如果当前数据库不支持公共表表达式，则返回空字符串。
+	GetCteKeyword(recursive bool) string
+	// 返回写入语句的操作关键字（如INSERT/REPLACE/INSERT IGNORE）以及写入语句末尾的冲突处理子句，
+	// 参数<table>为已转义的表名，<columns>为写入的字段名，<option>为写入选项。
+	//
+	// 默认使用mysql的语法，自定义驱动可以覆盖该方法以支持不同数据库的冲突处理语法(如pgsql/sqlite的ON CONFLICT)。
+	FormatUpsert(table string, columns []string, option DoInsertOption) (operation string, upsert string, err error)
+	// 返回写入语句中用于返回写入记录字段<columns>的子句，<output>位于VALUES之前(如mssql的OUTPUT INSERTED)，
+	// <returning>位于写入语句末尾(如pgsql/sqlite的RETURNING)，字段"*"表示返回全部字段。
+	//
+	// 如果当前数据库不支持返回写入的记录，则返回错误。
+	FormatReturning(columns []string) (output string, returning string, err error)
+	// 检查底层驱动是否支持通过sql.Result获取最后写入的id，不支持时写入操作通过返回子句获取主键的值。
+	IsLastInsertIdSupported() bool
+	// 检查底层驱动的原始错误是否为可重试的事务错误(如死锁、序列化失败)，用于事务的自动重试。
+	//
+	// 自定义驱动可以覆盖该方法声明自己的可重试错误。
+	IsRetryableError(err error) bool
 
 	// HandleSqlBeforeCommit 是一个钩子函数，它在将sql字符串提交到底层驱动程序之前处理该字符串。
 	//
@@ -263,6 +310,7 @@ type DB interface {
 	mappingAndFilterData(schema, table string, data map[string]interface{}, filter bool) (map[string]interface{}, error)
 	convertFieldValueToLocalValue(fieldValue interface{}, fieldType string) interface{}
 	convertRowsToResult(rows *sql.Rows) (Result, error)
+	doQueryContext(ctx context.Context, link Link, sql string, args ...interface{}) (rows *sql.Rows, err error)
 }
 
 // Core 是数据库管理的基本结构。Core只实现了DB接口一部分方法，剩下没实现的交给 DBDriver (数据库驱动)实现，这样DBDriver只要继承Core，就完全实现了DB接口。
@@ -308,6 +356,18 @@ type TableField struct {
 	Comment string      // 字段的注释。
 }
 
+// DoInsertOption 是DoInsert/DoBatchInsert的写入选项，通过FormatUpsert传递给驱动。
+type DoInsertOption struct {
+	InsertOption   int      // 写入操作类型：insert/replace/save/ignore。
+	BatchCount     int      // 批量写入时每批次的数量。
+	OnConflict     []string // 冲突检测的字段，用于pgsql/sqlite的ON CONFLICT子句，为空时根据表的主键/唯一索引自动检测。
+	OnDuplicate    []string // 保存操作冲突时更新的字段，更新为写入的值，为空时更新全部写入的字段。
+	OnDuplicateMap Map      // 保存操作冲突时更新的字段及其值，值可以是写入值的字段名称/Raw/Counter。
+	OnDuplicateEx  []string // 保存操作冲突时不更新的字段。
+	Returning      []string // 写入后返回的字段，通过SqlResult.Returning获取返回的记录。
+	PrimaryKey     string   // 表的主键字段，不为空时写入后返回该字段，用于获取最后写入的id。
+}
+
 // Link 是一个通用的数据库函数包装器接口。
 //
 // Link接口对象在master-slave模式下可能是一个主节点对象，也可能是从节点对象，因此如果在继承的驱动对象实现中使用该link接口对象时，注意当前的运行模式。
@@ -350,10 +410,19 @@ const (
 	ctxTimeoutTypePrepare
 )
 
+const (
+	savePointOperationCreate   = 1 // 创建保存点。
+	savePointOperationRollback = 2 // 回滚到保存点。
+	savePointOperationRelease  = 3 // 释放保存点。
+)
+
 var (
 	// ErrNoRows is alias of sql.ErrNoRows.
 	ErrNoRows = sql.ErrNoRows
 
+	// ErrOptimisticLock 是乐观锁检查失败的错误，即更新时记录的版本号已被修改或者记录不存在。
+	ErrOptimisticLock = gerror.New("optimistic lock failed: the record version has been changed")
+
 	// instances 是实例的管理映射。
 	instances = gmap.NewStrAnyMap(true)
 
@@ -15,6 +15,7 @@ import (
 	"github.com/gogf/gf/text/gstr"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/gogf/gf/internal/utils"
 
@@ -94,6 +95,9 @@ func (c *Core) Slave() (*sql.DB, error) {
 
 // Query 向基础驱动程序提交一个查询SQL并返回执行结果。它最常用于数据查询。
 func (c *Core) Query(sql string, args ...interface{}) (rows *sql.Rows, err error) {
+	if tx := TXFromCtx(c.DB.GetCtx(), c.DB.GetGroup()); tx != nil {
+		return c.DB.DoQuery(tx.tx, sql, args...)
+	}
 	link, err := c.DB.Slave()
 	if err != nil {
 		return nil, err
@@ -103,14 +107,21 @@ func (c *Core) Query(sql string, args ...interface{}) (rows *sql.Rows, err error
 
 // DoQuery 通过给定的链接对象将sql字符串及其参数提交给底层驱动程序，并返回执行结果。
 func (c *Core) DoQuery(link Link, sql string, args ...interface{}) (rows *sql.Rows, err error) {
-	sql, args = formatSql(sql, args)
-	sql, args = c.DB.HandleSqlBeforeCommit(link, sql, args)
 	ctx := c.DB.GetCtx()
 	if c.GetConfig().QueryTimeout > 0 {
 		var cancelFunc context.CancelFunc
 		ctx, cancelFunc = context.WithTimeout(ctx, c.GetConfig().QueryTimeout)
 		defer cancelFunc()
 	}
+	return c.DB.doQueryContext(ctx, link, sql, args...)
+}
+
+// doQueryContext 使用上下文<ctx>通过给定的链接对象将sql字符串及其参数提交给底层驱动程序，并返回执行结果。
+//
+// 请注意，在返回的结果集读取完成之前，<ctx>不能被取消。
+func (c *Core) doQueryContext(ctx context.Context, link Link, sql string, args ...interface{}) (rows *sql.Rows, err error) {
+	sql, args = formatSql(sql, args)
+	sql, args = c.DB.HandleSqlBeforeCommit(link, sql, args)
 
 	mTime1 := gtime.TimestampMilli()
 	rows, err = link.QueryContext(ctx, sql, args...)
@@ -139,6 +150,9 @@ func (c *Core) DoQuery(link Link, sql string, args ...interface{}) (rows *sql.Ro
 
 // Exec 向基础驱动程序提交一个查询SQL并返回执行结果。它最常用于数据插入和更新。
 func (c *Core) Exec(sql string, args ...interface{}) (result sql.Result, err error) {
+	if tx := TXFromCtx(c.DB.GetCtx(), c.DB.GetGroup()); tx != nil {
+		return c.DB.DoExec(tx.tx, sql, args...)
+	}
 	link, err := c.DB.Master()
 	if err != nil {
 		return nil, err
@@ -193,6 +207,9 @@ func (c *Core) Prepare(sql string, execOnMaster ...bool) (*Stmt, error) {
 		err  error
 		link Link
 	)
+	if tx := TXFromCtx(c.DB.GetCtx(), c.DB.GetGroup()); tx != nil {
+		return c.DB.DoPrepare(tx.tx, sql)
+	}
 	if len(execOnMaster) > 0 && execOnMaster[0] {
 		if link, err = c.DB.Master(); err != nil {
 			return nil, err
@@ -245,6 +262,11 @@ func (c *Core) All(sql string, args ...interface{}) (Result, error) {
 
 // DoGetAll 查询并返回数据库中的数据记录。
 func (c *Core) DoGetAll(link Link, sql string, args ...interface{}) (result Result, err error) {
+	if link == nil {
+		if tx := TXFromCtx(c.DB.GetCtx(), c.DB.GetGroup()); tx != nil {
+			link = tx.tx
+		}
+	}
 	if link == nil {
 		link, err = c.DB.Slave()
 		if err != nil {
@@ -376,35 +398,108 @@ func (c *Core) PingSlave() error {
 //
 // 提交或回滚函数也会自动关闭事务。
 func (c *Core) Begin() (*TX, error) {
-	if master, err := c.DB.Master(); err != nil {
-		return nil, err
+	return c.DB.BeginWithOptions(c.DB.GetCtx(), TxOptions{})
+}
+
+// BeginWithOptions 使用给定的上下文<ctx>和选项<options>启动并返回事务对象。
+//
+// 只读事务(options.ReadOnly)将在从节点上开启，如果没有配置从节点则使用主节点。
+//
+// 事务的超时时间优先使用options.Timeout，其次使用配置项TranTimeout，超时上下文在事务提交或回滚后才会被取消。
+func (c *Core) BeginWithOptions(ctx context.Context, options TxOptions) (*TX, error) {
+	if ctx == nil {
+		ctx = c.DB.GetCtx()
+	}
+	var (
+		err  error
+		link *sql.DB
+	)
+	if options.ReadOnly {
+		link, err = c.DB.Slave()
 	} else {
-		ctx := c.DB.GetCtx()
-		if c.GetConfig().TranTimeout > 0 {
-			var cancelFunc context.CancelFunc
-			ctx, cancelFunc = context.WithTimeout(ctx, c.GetConfig().TranTimeout)
-			defer cancelFunc()
-		}
-		if tx, err := master.BeginTx(ctx, nil); err == nil {
-			return &TX{
-				db:     c.DB,
-				tx:     tx,
-				master: master,
-			}, nil
-		} else {
-			return nil, err
+		link, err = c.DB.Master()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var (
+		txCtx      = ctx
+		timeout    = options.Timeout
+		cancelFunc context.CancelFunc
+	)
+	if timeout <= 0 {
+		timeout = c.GetConfig().TranTimeout
+	}
+	if timeout > 0 {
+		txCtx, cancelFunc = context.WithTimeout(ctx, timeout)
+	}
+	tx, err := link.BeginTx(txCtx, &sql.TxOptions{
+		Isolation: options.Isolation,
+		ReadOnly:  options.ReadOnly,
+	})
+	if err != nil {
+		if cancelFunc != nil {
+			cancelFunc()
 		}
+		return nil, err
+	}
+	newTx := &TX{
+		db:         c.DB.Ctx(ctx),
+		tx:         tx,
+		master:     link,
+		cancelFunc: cancelFunc,
 	}
+	newTx.ctx = WithTX(ctx, newTx)
+	return newTx, nil
 }
 
 // Transaction 使用函数<f>包装事务逻辑。
 //
 // 它回滚事务，如果返回非nil错误，则从函数<f>返回错误，如果函数<f>返回nil，则提交事务并返回nil。
 //
+// 如果当前上下文中已经存在同一配置组的事务对象(参见TX.GetCtx/WithTX)，那么它将使用保存点在该事务中开启嵌套事务，
+// 而不是在新的连接上开启一个无关的事务。
+//
 //注意: 您不应该在函数<f>中提交或回滚事务，因为它是由该函数自动处理的。
 func (c *Core) Transaction(f func(tx *TX) error) (err error) {
+	return c.DB.TransactionWithOptions(c.DB.GetCtx(), TxOptions{}, f)
+}
+
+// TransactionWithOptions 使用给定的上下文<ctx>和选项<options>开启事务，并使用函数<f>包装事务逻辑。
+//
+// 它的提交与回滚逻辑同Transaction，注意: 嵌套事务沿用外层事务的选项，参数<options>将被忽略。
+//
+// 如果配置了重试策略(options.Retry或配置项TranRetryAttempts)，当事务因死锁、序列化失败等可重试错误失败时，
+// 它将回滚事务并在等待一段时间后重新执行函数<f>，因此函数<f>应当是可以重复执行的。
+func (c *Core) TransactionWithOptions(ctx context.Context, options TxOptions, f func(tx *TX) error) (err error) {
+	if ctx == nil {
+		ctx = c.DB.GetCtx()
+	}
+	if tx := TXFromCtx(ctx, c.DB.GetGroup()); tx != nil {
+		return tx.Transaction(f)
+	}
+	policy := options.Retry
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = c.GetConfig().TranRetryAttempts
+		policy.Interval = c.GetConfig().TranRetryInterval
+	}
+	for attempt := 1; ; attempt++ {
+		err = c.doTransactionWithOptions(ctx, options, f)
+		if err == nil || attempt >= policy.MaxAttempts || !c.isRetryableError(err, policy) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(policy.backoff(attempt)):
+		}
+	}
+}
+
+// doTransactionWithOptions 开启事务并执行一次函数<f>，根据其结果提交或回滚事务。
+func (c *Core) doTransactionWithOptions(ctx context.Context, options TxOptions, f func(tx *TX) error) (err error) {
 	var tx *TX
-	tx, err = c.DB.Begin()
+	tx, err = c.DB.BeginWithOptions(ctx, options)
 	if err != nil {
 		return err
 	}
@@ -428,6 +523,20 @@ func (c *Core) Transaction(f func(tx *TX) error) (err error) {
 	return
 }
 
+// isRetryableError 检查事务错误<err>是否可以通过重新执行事务来解决。
+func (c *Core) isRetryableError(err error, policy TxRetryPolicy) bool {
+	cause := gerror.Cause(err)
+	if policy.Retryable != nil {
+		return policy.Retryable(cause)
+	}
+	return c.DB.IsRetryableError(cause)
+}
+
+// IsRetryableError 检查底层驱动的原始错误<err>是否为可重试的事务错误(如死锁、序列化失败)，它默认情况下返回false。
+func (c *Core) IsRetryableError(err error) bool {
+	return false
+}
+
 // Insert 对表执行“insert into…”语句。
 // 如果表中已经有一个唯一的数据记录，它将返回error。
 //
@@ -526,6 +635,7 @@ func (c *Core) Save(table string, data interface{}, batch ...int) (sql.Result, e
 func (c *Core) DoInsert(link Link, table string, data interface{}, option int, batch ...int) (result sql.Result, err error) {
 	table = c.DB.QuotePrefixTableName(table)
 	var (
+		insertOption = c.getDoInsertOption(option, batch...)
 		fields       []string
 		values       []string
 		params       []interface{}
@@ -556,10 +666,10 @@ func (c *Core) DoInsert(link Link, table string, data interface{}, option int, b
 	}
 	var (
 		charL, charR = c.DB.GetChars()
-		operation    = GetInsertOperationByOption(option)
-		updateStr    = ""
+		columns      = make([]string, 0, len(dataMap))
 	)
 	for k, v := range dataMap {
+		columns = append(columns, k)
 		fields = append(fields, charL+k+charR)
 		if s, ok := v.(Raw); ok {
 			values = append(values, gconv.String(s))
@@ -568,38 +678,36 @@ func (c *Core) DoInsert(link Link, table string, data interface{}, option int, b
 			params = append(params, v)
 		}
 	}
-	if option == insertOptionSave {
-		for k, _ := range dataMap {
-			// If it's SAVE operation,
-			// do not automatically update the creating time.
-			if c.isSoftCreatedFiledName(k) {
-				continue
-			}
-			if len(updateStr) > 0 {
-				updateStr += ","
-			}
-			updateStr += fmt.Sprintf(
-				"%s%s%s=VALUES(%s%s%s)",
-				charL, k, charR,
-				charL, k, charR,
-			)
-		}
-		updateStr = fmt.Sprintf("ON DUPLICATE KEY UPDATE %s", updateStr)
+	operation, updateStr, err := c.DB.FormatUpsert(table, columns, insertOption)
+	if err != nil {
+		return nil, err
+	}
+	returningColumns := c.getReturningColumns(insertOption)
+	outputStr, returningStr, err := c.DB.FormatReturning(returningColumns)
+	if err != nil {
+		return nil, err
+	}
+	if outputStr != "" {
+		outputStr += " "
 	}
 	if link == nil {
 		if link, err = c.DB.Master(); err != nil {
 			return nil, err
 		}
 	}
-	return c.DB.DoExec(
-		link,
-		fmt.Sprintf(
-			"%s INTO %s(%s) VALUES(%s) %s",
-			operation, table, strings.Join(fields, ","),
-			strings.Join(values, ","), updateStr,
-		),
-		params...,
+	sqlStr := fmt.Sprintf(
+		"%s INTO %s(%s) %sVALUES(%s) %s",
+		operation, table, strings.Join(fields, ","), outputStr,
+		strings.Join(values, ","), updateStr,
 	)
+	if len(returningColumns) > 0 {
+		r, err := c.doExecReturning(link, insertOption.PrimaryKey, sqlStr+" "+returningStr, params...)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	return c.DB.DoExec(link, sqlStr, params...)
 }
 
 // BatchInsert 批量插入数据。
@@ -642,6 +750,7 @@ func (c *Core) BatchSave(table string, list interface{}, batch ...int) (sql.Resu
 // 此函数通常用于自定义接口定义，不需要手动调用。
 func (c *Core) DoBatchInsert(link Link, table string, list interface{}, option int, batch ...int) (result sql.Result, err error) {
 	table = c.DB.QuotePrefixTableName(table)
+	insertOption := c.getDoInsertOption(option, batch...)
 	var (
 		keys    []string      // 字段名。
 		values  []string      // 值持有者字符串数组，如：（？,?,?)
@@ -709,30 +818,20 @@ func (c *Core) DoBatchInsert(link Link, table string, list interface{}, option i
 		charL, charR = c.DB.GetChars()
 		batchResult  = new(SqlResult)
 		keysStr      = charL + strings.Join(keys, charR+","+charL) + charR
-		operation    = GetInsertOperationByOption(option)
-		updateStr    = ""
 	)
-	if option == insertOptionSave {
-		for _, k := range keys {
-			// 如果是保存操作，不要自动更新创建时间。
-			if c.isSoftCreatedFiledName(k) {
-				continue
-			}
-			if len(updateStr) > 0 {
-				updateStr += ","
-			}
-			updateStr += fmt.Sprintf(
-				"%s%s%s=VALUES(%s%s%s)",
-				charL, k, charR,
-				charL, k, charR,
-			)
-		}
-		updateStr = fmt.Sprintf("ON DUPLICATE KEY UPDATE %s", updateStr)
+	operation, updateStr, err := c.DB.FormatUpsert(table, keys, insertOption)
+	if err != nil {
+		return nil, err
 	}
-	batchNum := defaultBatchNumber
-	if len(batch) > 0 && batch[0] > 0 {
-		batchNum = batch[0]
+	returningColumns := c.getReturningColumns(insertOption)
+	outputStr, returningStr, err := c.DB.FormatReturning(returningColumns)
+	if err != nil {
+		return nil, err
+	}
+	if outputStr != "" {
+		outputStr += " "
 	}
+	batchNum := insertOption.BatchCount
 	var (
 		listMapLen  = len(listMap)
 		valueHolder = make([]string, 0)
@@ -750,16 +849,25 @@ func (c *Core) DoBatchInsert(link Link, table string, list interface{}, option i
 		}
 		valueHolder = append(valueHolder, "("+gstr.Join(values, ",")+")")
 		if len(valueHolder) == batchNum || (i == listMapLen-1 && len(valueHolder) > 0) {
-			r, err := c.DB.DoExec(
-				link,
-				fmt.Sprintf(
-					"%s INTO %s(%s) VALUES%s %s",
-					operation, table, keysStr,
-					gstr.Join(valueHolder, ","),
-					updateStr,
-				),
-				params...,
+			sqlStr := fmt.Sprintf(
+				"%s INTO %s(%s) %sVALUES%s %s",
+				operation, table, keysStr, outputStr,
+				gstr.Join(valueHolder, ","),
+				updateStr,
 			)
+			if len(returningColumns) > 0 {
+				r, err := c.doExecReturning(link, insertOption.PrimaryKey, sqlStr+" "+returningStr, params...)
+				if err != nil {
+					return nil, err
+				}
+				batchResult.insertIdKey = r.insertIdKey
+				batchResult.returning = append(batchResult.returning, r.returning...)
+				batchResult.affected += r.affected
+				params = params[:0]
+				valueHolder = valueHolder[:0]
+				continue
+			}
+			r, err := c.DB.DoExec(link, sqlStr, params...)
 			if err != nil {
 				return r, err
 			}
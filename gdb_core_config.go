@@ -27,30 +27,36 @@ type ConfigGroup []ConfigNode
 
 // ConfigNode 是一个节点的配置。
 type ConfigNode struct {
-	Host                 string        `json:"host"`                 // 服务器、ip或域的主机，如：127.0.0.1，localhost
-	Port                 string        `json:"port"`                 // Port, 一般是3306。
-	User                 string        `json:"user"`                 // 身份验证用户名。
-	Pass                 string        `json:"pass"`                 // 身份验证密码。
-	Name                 string        `json:"name"`                 // Default used database name.
-	Type                 string        `json:"type"`                 // 数据库类型：mysql、sqlite、mssql、pgsql、oracle。
-	Role                 string        `json:"role"`                 // (可选，默认为“主”)节点角色，用于主从模式：主、从。
-	Debug                bool          `json:"debug"`                // (Optional) 调试模式启用调试信息记录和输出。
-	Prefix               string        `json:"prefix"`               // (Optional) 表前缀。
-	DryRun               bool          `json:"dryRun"`               // (Optional) Dry run，不选择INSERT/UPDATE/DELETE语句。
-	Weight               int           `json:"weight"`               // (Optional) 用于负载平衡计算的权重，如果只有一个节点就没有用了。
-	Charset              string        `json:"charset"`              // (Optional, "utf8mb4" in default) 在数据库上操作时的自定义字符集。
-	LinkInfo             string        `json:"link"`                 // (Optional) 使用自定义链接信息时，配置主机/Port/User/Pass/Name将被忽略。
-	MaxIdleConnCount     int           `json:"maxIdle"`              // (Optional) 基础连接池的最大空闲连接配置。
-	MaxOpenConnCount     int           `json:"maxOpen"`              // (Optional) 基础连接池的最大打开连接配置。
-	MaxConnLifetime      time.Duration `json:"maxLifetime"`          // (Optional) 基础连接池的最大连接TTL配置。
-	QueryTimeout         time.Duration `json:"queryTimeout"`         // (Optional) 每个dql的最大查询时间。
-	ExecTimeout          time.Duration `json:"execTimeout"`          // (Optional) dml的最长执行时间。
-	TranTimeout          time.Duration `json:"tranTimeout"`          // (Optional) 事务的最大执行时间。
-	PrepareTimeout       time.Duration `json:"prepareTimeout"`       // (Optional) 预加载操作的最大执行时间。
-	CreatedAt            string        `json:"createdAt"`            // (Optional) 用于自动填充创建日期时间的表的文件名。
-	UpdatedAt            string        `json:"updatedAt"`            // (Optional) 用于自动填充更新日期时间的表的文件名。
-	DeletedAt            string        `json:"deletedAt"`            // (Optional) 用于自动填充更新日期时间的表的文件名。
-	TimeMaintainDisabled bool          `json:"timeMaintainDisabled"` // (Optional) 禁用自动计时功能。
+	Host                 string            `json:"host"`                 // 服务器、ip或域的主机，如：127.0.0.1，localhost
+	Port                 string            `json:"port"`                 // Port, 一般是3306。
+	User                 string            `json:"user"`                 // 身份验证用户名。
+	Pass                 string            `json:"pass"`                 // 身份验证密码。
+	Name                 string            `json:"name"`                 // Default used database name.
+	Type                 string            `json:"type"`                 // 数据库类型：mysql、sqlite、mssql、pgsql、oracle。
+	Role                 string            `json:"role"`                 // (可选，默认为“主”)节点角色，用于主从模式：主、从。
+	Debug                bool              `json:"debug"`                // (Optional) 调试模式启用调试信息记录和输出。
+	Prefix               string            `json:"prefix"`               // (Optional) 表前缀。
+	DryRun               bool              `json:"dryRun"`               // (Optional) Dry run，不选择INSERT/UPDATE/DELETE语句。
+	Weight               int               `json:"weight"`               // (Optional) 用于负载平衡计算的权重，如果只有一个节点就没有用了。
+	Charset              string            `json:"charset"`              // (Optional, "utf8mb4" in default) 在数据库上操作时的自定义字符集。
+	LinkInfo             string            `json:"link"`                 // (Optional) 使用自定义链接信息时，配置主机/Port/User/Pass/Name将被忽略。
+	MaxIdleConnCount     int               `json:"maxIdle"`              // (Optional) 基础连接池的最大空闲连接配置。
+	MaxOpenConnCount     int               `json:"maxOpen"`              // (Optional) 基础连接池的最大打开连接配置。
+	MaxConnLifetime      time.Duration     `json:"maxLifetime"`          // (Optional) 基础连接池的最大连接TTL配置。
+	QueryTimeout         time.Duration     `json:"queryTimeout"`         // (Optional) 每个dql的最大查询时间。
+	ExecTimeout          time.Duration     `json:"execTimeout"`          // (Optional) dml的最长执行时间。
+	TranTimeout          time.Duration     `json:"tranTimeout"`          // (Optional) 事务的最大执行时间。
+	TranRetryAttempts    int               `json:"tranRetryAttempts"`    // (Optional) 事务遇到死锁等可重试错误时的最大执行次数，默认不重试。
+	TranRetryInterval    time.Duration     `json:"tranRetryInterval"`    // (Optional) 事务首次重试前的等待时间，之后每次翻倍。
+	PrepareTimeout       time.Duration     `json:"prepareTimeout"`       // (Optional) 预加载操作的最大执行时间。
+	CreatedAt            string            `json:"createdAt"`            // (Optional) 用于自动填充创建日期时间的表的文件名。
+	UpdatedAt            string            `json:"updatedAt"`            // (Optional) 用于自动填充更新日期时间的表的文件名。
+	DeletedAt            string            `json:"deletedAt"`            // (Optional) 用于自动填充更新日期时间的表的文件名。
+	TimeMaintainDisabled bool              `json:"timeMaintainDisabled"` // (Optional) 禁用自动计时功能。
+	TimeZone             string            `json:"timeZone"`             // (Optional) 自动计时使用的时区，如：Asia/Shanghai，默认使用本地时区。
+	TimePrecision        string            `json:"timePrecision"`        // (Optional) 自动计时的精度：second、millisecond，默认根据字段类型自动选择。
+	VersionField         string            `json:"versionField"`         // (Optional) 用于乐观锁的版本号字段名，表中存在该字段时Update自动检查并递增版本号。
+	SoftDeleteStrategy   map[string]string `json:"softDeleteStrategy"`   // (Optional) 按表名配置的软删除策略：datetime、unix、unixmilli、flag、bool或者自定义注册的名称，未配置的表根据软删除字段的类型自动选择。
 }
 
 // configs 是内部使用的配置对象。
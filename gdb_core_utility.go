@@ -8,7 +8,14 @@
 package gdb
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/gogf/gf/errors/gerror"
+	"github.com/gogf/gf/text/gstr"
+	"github.com/gogf/gf/util/gconv"
 )
 
 // GetMaster 作用类似于函数主控，但带有指定连接模式的附加<schema>参数，它是为内部用法。还有见 Master.
@@ -62,6 +69,305 @@ func (c *Core) HandleSqlBeforeCommit(sql string) string {
 	return sql
 }
 
+// GetSavePointSql 返回保存点操作的sql语句，默认使用mysql/pgsql/sqlite通用的标准语法。
+func (c *Core) GetSavePointSql(operation int, name string) string {
+	switch operation {
+	case savePointOperationCreate:
+		return "SAVEPOINT " + name
+	case savePointOperationRollback:
+		return "ROLLBACK TO SAVEPOINT " + name
+	case savePointOperationRelease:
+		return "RELEASE SAVEPOINT " + name
+	}
+	return ""
+}
+
+// GetUnionSql 返回组合查询的sql语句，默认每个查询语句使用括号包裹，以支持各查询语句独立的排序和分页，
+// 适用于mysql/pgsql/mssql/oracle。
+func (c *Core) GetUnionSql(unionType string, sqlList []string) string {
+	array := make([]string, len(sqlList))
+	for k, v := range sqlList {
+		array[k] = "(" + v + ")"
+	}
+	return gstr.Join(array, " "+unionType+" ")
+}
+
+// GetCteKeyword 返回公共表表达式语句的起始关键字，默认使用mysql(8.0+)/pgsql/sqlite通用的标准语法。
+func (c *Core) GetCteKeyword(recursive bool) string {
+	if recursive {
+		return "WITH RECURSIVE"
+	}
+	return "WITH"
+}
+
+// FormatUpsert 返回写入语句的操作关键字以及冲突处理子句，默认使用mysql的语法:
+// REPLACE/INSERT IGNORE，以及Save操作的ON DUPLICATE KEY UPDATE子句，没有需要更新的字段时Save操作使用INSERT IGNORE。
+func (c *Core) FormatUpsert(table string, columns []string, option DoInsertOption) (operation string, upsert string, err error) {
+	operation = GetInsertOperationByOption(option.InsertOption)
+	if option.InsertOption != insertOptionSave {
+		return
+	}
+	updateArray := c.formatUpsertUpdates(columns, nil, option, "VALUES(%s)", "")
+	if len(updateArray) == 0 {
+		return GetInsertOperationByOption(insertOptionIgnore), "", nil
+	}
+	upsert = fmt.Sprintf("ON DUPLICATE KEY UPDATE %s", gstr.Join(updateArray, ","))
+	return
+}
+
+// formatOnConflictUpsert 返回pgsql/sqlite使用ON CONFLICT语法的写入语句的操作关键字以及冲突处理子句:
+//
+// ignore:       ON CONFLICT (keys) DO NOTHING
+//
+// save/replace: ON CONFLICT (keys) DO UPDATE SET col=EXCLUDED.col
+//
+// 其中save操作不会更新创建时间字段，更新的字段可以通过<option>的OnDuplicate/OnDuplicateMap/OnDuplicateEx指定，
+// 冲突检测的字段由<option>指定或根据表的主键/唯一索引自动检测。
+func (c *Core) formatOnConflictUpsert(table string, columns []string, option DoInsertOption) (operation string, upsert string, err error) {
+	operation = "INSERT"
+	if option.InsertOption == insertOptionDefault {
+		return
+	}
+	var (
+		charL, charR    = c.DB.GetChars()
+		conflictColumns = option.OnConflict
+	)
+	if len(conflictColumns) == 0 {
+		if conflictColumns, err = c.getConflictColumns(table, columns); err != nil {
+			return "", "", err
+		}
+	}
+	conflictStr := ""
+	if len(conflictColumns) > 0 {
+		conflictStr = fmt.Sprintf("(%s%s%s) ", charL, gstr.Join(conflictColumns, charR+","+charL), charR)
+	}
+	if option.InsertOption == insertOptionIgnore {
+		return operation, fmt.Sprintf("ON CONFLICT %sDO NOTHING", conflictStr), nil
+	}
+	if conflictStr == "" {
+		return "", "", gerror.Newf(
+			`cannot find the conflict columns of table "%s", please specify them using Model.OnConflict`, table,
+		)
+	}
+	updateArray := c.formatUpsertUpdates(columns, conflictColumns, option, "EXCLUDED.%s", table+".")
+	if len(updateArray) == 0 {
+		return operation, fmt.Sprintf("ON CONFLICT %sDO NOTHING", conflictStr), nil
+	}
+	return operation, fmt.Sprintf("ON CONFLICT %sDO UPDATE SET %s", conflictStr, gstr.Join(updateArray, ",")), nil
+}
+
+// formatUpsertUpdates 返回写入数据冲突时的更新字段数组，如: `col`=VALUES(`col`)。
+//
+// Save操作可以通过<option>的OnDuplicate/OnDuplicateMap指定更新的字段，未指定时更新全部写入字段<columns>，
+// 其中<excludes>中的字段以及Save操作的创建时间字段不会被更新，OnDuplicateEx中的字段总是不会被更新。
+//
+// 参数<valueFormat>是引用写入值的格式，如: VALUES(%s)；<qualifier>是Counter引用原有字段值时的前缀，如表名。
+func (c *Core) formatUpsertUpdates(columns, excludes []string, option DoInsertOption, valueFormat, qualifier string) []string {
+	var (
+		updateArray = make([]string, 0, len(columns))
+		isExcluded  = func(column string) bool {
+			return gstr.InArray(option.OnDuplicateEx, column)
+		}
+	)
+	if option.InsertOption == insertOptionSave && (len(option.OnDuplicate) > 0 || len(option.OnDuplicateMap) > 0) {
+		for _, k := range option.OnDuplicate {
+			if isExcluded(k) {
+				continue
+			}
+			column := c.DB.QuoteWord(k)
+			updateArray = append(updateArray, column+"="+fmt.Sprintf(valueFormat, column))
+		}
+		// map类型是无序的，按照字段名称排序以生成固定的语句。
+		keys := make([]string, 0, len(option.OnDuplicateMap))
+		for k := range option.OnDuplicateMap {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if isExcluded(k) {
+				continue
+			}
+			column := c.DB.QuoteWord(k)
+			switch v := option.OnDuplicateMap[k].(type) {
+			case Raw:
+				updateArray = append(updateArray, column+"="+gconv.String(v))
+			case *Counter:
+				updateArray = append(updateArray, c.formatUpsertCounter(column, *v, qualifier))
+			case Counter:
+				updateArray = append(updateArray, c.formatUpsertCounter(column, v, qualifier))
+			default:
+				updateArray = append(updateArray, column+"="+fmt.Sprintf(valueFormat, c.DB.QuoteWord(gconv.String(v))))
+			}
+		}
+		return updateArray
+	}
+	for _, k := range columns {
+		if isExcluded(k) || gstr.InArray(excludes, k) {
+			continue
+		}
+		// 如果是保存操作，不要自动更新创建时间。
+		if option.InsertOption == insertOptionSave && c.isSoftCreatedFiledName(k) {
+			continue
+		}
+		column := c.DB.QuoteWord(k)
+		updateArray = append(updateArray, column+"="+fmt.Sprintf(valueFormat, column))
+	}
+	return updateArray
+}
+
+// formatUpsertCounter 返回字段<column>冲突时按照<counter>计数更新的语句，如: `count`=`count`+1。
+func (c *Core) formatUpsertCounter(column string, counter Counter, qualifier string) string {
+	field := column
+	if counter.Field != "" {
+		field = c.DB.QuoteWord(counter.Field)
+	}
+	return fmt.Sprintf("%s=%s%s+%s", column, qualifier, field, gconv.String(counter.Value))
+}
+
+// getConflictColumns 根据表<table>的主键/唯一索引信息检索并返回写入字段<columns>中用于冲突检测的字段。
+//
+// 如果写入字段包含全部主键字段则返回主键字段，否则返回第一个包含在写入字段中的唯一索引字段，都不存在时返回空。
+func (c *Core) getConflictColumns(table string, columns []string) ([]string, error) {
+	fields, err := c.DB.TableFields(table)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		primaryFields = make([]*TableField, 0)
+		uniqueFields  = make([]*TableField, 0)
+	)
+	for _, field := range fields {
+		switch field.Key {
+		case "PRI":
+			primaryFields = append(primaryFields, field)
+		case "UNI":
+			if gstr.InArray(columns, field.Name) {
+				uniqueFields = append(uniqueFields, field)
+			}
+		}
+	}
+	// 表字段是无序的，需要按照字段的Index排序。
+	sort.Slice(primaryFields, func(i, j int) bool { return primaryFields[i].Index < primaryFields[j].Index })
+	sort.Slice(uniqueFields, func(i, j int) bool { return uniqueFields[i].Index < uniqueFields[j].Index })
+	if len(primaryFields) > 0 {
+		primaryColumns := make([]string, 0, len(primaryFields))
+		for _, field := range primaryFields {
+			if !gstr.InArray(columns, field.Name) {
+				primaryColumns = nil
+				break
+			}
+			primaryColumns = append(primaryColumns, field.Name)
+		}
+		if len(primaryColumns) > 0 {
+			return primaryColumns, nil
+		}
+	}
+	if len(uniqueFields) > 0 {
+		return []string{uniqueFields[0].Name}, nil
+	}
+	return nil, nil
+}
+
+// FormatReturning 返回写入语句中用于返回写入记录的子句，mysql不支持返回写入的记录，因此<columns>不为空时返回错误。
+func (c *Core) FormatReturning(columns []string) (output string, returning string, err error) {
+	if len(columns) > 0 {
+		return "", "", gerror.New("returning inserted records is not supported by current database")
+	}
+	return "", "", nil
+}
+
+// IsLastInsertIdSupported 检查底层驱动是否支持通过sql.Result获取最后写入的id，默认返回true。
+func (c *Core) IsLastInsertIdSupported() bool {
+	return true
+}
+
+// formatReturningColumns 将返回字段<columns>转义并使用<prefix>前缀连接，如: INSERTED."id",INSERTED."name"。
+func (c *Core) formatReturningColumns(columns []string, prefix string) string {
+	array := make([]string, len(columns))
+	for k, v := range columns {
+		if v == "*" {
+			array[k] = prefix + v
+		} else {
+			array[k] = prefix + c.DB.QuoteWord(v)
+		}
+	}
+	return gstr.Join(array, ",")
+}
+
+// doInsertOptionKeyForContext 是Model写入时DoInsertOption保存在上下文中的键，使用私有类型以免与其他包的键冲突。
+type doInsertOptionKeyForContext struct{}
+
+// WithDoInsertOption 将写入选项<option>注入到上下文<ctx>中并返回新的上下文。
+//
+// 为了保持DoInsert/DoBatchInsert的方法签名不变，Model写入时的冲突处理、返回字段等扩展选项通过上下文传递。
+func WithDoInsertOption(ctx context.Context, option DoInsertOption) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, doInsertOptionKeyForContext{}, option)
+}
+
+// DoInsertOptionFromCtx 从上下文<ctx>中检索并返回写入选项，如果不存在则返回空的写入选项。
+//
+// 自定义驱动实现DoInsert/DoBatchInsert时可以通过该方法获取Model写入时的冲突处理、返回字段等扩展选项，如:
+// DoInsertOptionFromCtx(d.GetCtx())
+func DoInsertOptionFromCtx(ctx context.Context) DoInsertOption {
+	if ctx == nil {
+		return DoInsertOption{}
+	}
+	option, _ := ctx.Value(doInsertOptionKeyForContext{}).(DoInsertOption)
+	return option
+}
+
+// getDoInsertOption 返回DoInsert/DoBatchInsert的写入选项，<option>为写入操作类型，<batch>为批量写入时每批次的数量。
+func (c *Core) getDoInsertOption(option int, batch ...int) DoInsertOption {
+	insertOption := DoInsertOptionFromCtx(c.DB.GetCtx())
+	insertOption.InsertOption = option
+	if len(batch) > 0 && batch[0] > 0 {
+		insertOption.BatchCount = batch[0]
+	}
+	if insertOption.BatchCount <= 0 {
+		insertOption.BatchCount = defaultBatchNumber
+	}
+	return insertOption
+}
+
+// getReturningColumns 返回写入选项<option>中需要返回的字段，包括用于获取最后写入id的主键字段。
+func (c *Core) getReturningColumns(option DoInsertOption) []string {
+	if option.PrimaryKey == "" || gstr.InArray(option.Returning, "*") || gstr.InArray(option.Returning, option.PrimaryKey) {
+		return option.Returning
+	}
+	columns := make([]string, 0, len(option.Returning)+1)
+	columns = append(columns, option.Returning...)
+	return append(columns, option.PrimaryKey)
+}
+
+// doExecReturning 通过给定的链接对象执行带有返回子句的写入语句<sql>，并返回包含写入语句返回记录的执行结果，
+// 参数<insertIdKey>为用于获取最后写入id的返回字段。
+func (c *Core) doExecReturning(link Link, insertIdKey string, sql string, args ...interface{}) (*SqlResult, error) {
+	result := &SqlResult{insertIdKey: insertIdKey}
+	if c.DB.GetDryRun() {
+		_, err := c.DB.DoExec(link, sql, args...)
+		return result, err
+	}
+	ctx := c.DB.GetCtx()
+	if c.GetConfig().ExecTimeout > 0 {
+		var cancelFunc context.CancelFunc
+		ctx, cancelFunc = context.WithTimeout(ctx, c.GetConfig().ExecTimeout)
+		defer cancelFunc()
+	}
+	rows, err := c.DB.doQueryContext(ctx, link, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if result.returning, err = c.DB.convertRowsToResult(rows); err != nil {
+		return nil, err
+	}
+	result.affected = int64(len(result.returning))
+	return result, nil
+}
+
 // Tables 检索并返回当前架构的表，它主要用于cli工具链中自动生成模型。它默认情况下不执行任何操作。
 func (c *Core) Tables(schema ...string) (tables []string, err error) {
 	return
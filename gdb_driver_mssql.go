@@ -7,7 +7,7 @@
 // Note:
 // 1. It needs manually import: _ "github.com/denisenkom/go-mssqldb"
 // 2. It does not support Save/Replace features.
-// 3. It does not support LastInsertId.
+// 3. It implements LastInsertId using OUTPUT clause for the inserting of Model.
 
 package gdb
 
@@ -88,9 +88,65 @@ func (d *DriverMssql) HandleSqlBeforeCommit(link Link, sql string, args []interf
 	return d.parseSql(str), args
 }
 
+// GetSavePointSql returns the sql statement of savepoint operation for SQL server.
+// Note that SQL server does not support releasing a savepoint, it returns empty string for that.
+func (d *DriverMssql) GetSavePointSql(operation int, name string) string {
+	switch operation {
+	case savePointOperationCreate:
+		return "SAVE TRANSACTION " + name
+	case savePointOperationRollback:
+		return "ROLLBACK TRANSACTION " + name
+	}
+	return ""
+}
+
+// GetCteKeyword returns the keyword of common table expressions for SQL server.
+// SQL server does not use the RECURSIVE keyword for recursive common table expressions.
+func (d *DriverMssql) GetCteKeyword(recursive bool) string {
+	return "WITH"
+}
+
+// IsRetryableError checks whether the error is a deadlock error of SQL server,
+// which can be resolved by re-running the transaction.
+func (d *DriverMssql) IsRetryableError(err error) bool {
+	if e, ok := err.(interface{ SQLErrorNumber() int32 }); ok {
+		// 1205: the transaction was deadlocked and has been chosen as the deadlock victim.
+		return e.SQLErrorNumber() == 1205
+	}
+	return false
+}
+
+// FormatReturning returns the OUTPUT clause for SQL server, which is placed before the VALUES clause.
+// Note that the OUTPUT clause cannot be used on tables that have enabled triggers.
+func (d *DriverMssql) FormatReturning(columns []string) (output string, returning string, err error) {
+	if len(columns) == 0 {
+		return "", "", nil
+	}
+	return "OUTPUT " + d.formatReturningColumns(columns, "INSERTED."), "", nil
+}
+
+// IsLastInsertIdSupported returns false as the LastInsertId is not supported by SQL server drivers,
+// the inserted primary key is retrieved using the OUTPUT clause only if Model.Returning is called.
+func (d *DriverMssql) IsLastInsertIdSupported() bool {
+	return false
+}
+
+// isReturningOnDemand returns true as the OUTPUT clause cannot be used on tables that have enabled triggers,
+// so the inserted primary key is not returned automatically for the insert statements without Model.Returning.
+func (d *DriverMssql) isReturningOnDemand() bool {
+	return true
+}
+
 // parseSql does some replacement of the sql before commits it to underlying driver,
 // for support of microsoft sql server.
+//
+// The sub query sql, including the select statements of union, is parsed separately.
 func (d *DriverMssql) parseSql(sql string) string {
+	return parseSqlWithSubQuery(sql, d.doParseSql)
+}
+
+// doParseSql parses the sql statement whose sub queries are replaced with placeholders.
+func (d *DriverMssql) doParseSql(sql string) string {
 	// SELECT * FROM USER WHERE ID=1 LIMIT 1
 	if m, _ := gregex.MatchString(`^SELECT(.+)LIMIT 1$`, sql); len(m) > 1 {
 		return fmt.Sprintf(`SELECT TOP 1 %s`, m[1])
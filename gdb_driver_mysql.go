@@ -14,7 +14,7 @@ import (
 	"github.com/gogf/gf/text/gregex"
 	"github.com/gogf/gf/text/gstr"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 // DriverMysql is the driver for mysql database.
@@ -79,6 +79,16 @@ func (d *DriverMysql) HandleSqlBeforeCommit(link Link, sql string, args []interf
 	return sql, args
 }
 
+// IsRetryableError checks whether the error is a deadlock error of mysql,
+// which can be resolved by re-running the transaction.
+func (d *DriverMysql) IsRetryableError(err error) bool {
+	if e, ok := err.(*mysql.MySQLError); ok {
+		// 1213: ER_LOCK_DEADLOCK.
+		return e.Number == 1213
+	}
+	return false
+}
+
 // Tables retrieves and returns the tables of current schema.
 // It's mainly used in cli tool chain for automatically generating the models.
 func (d *DriverMysql) Tables(schema ...string) (tables []string, err error) {
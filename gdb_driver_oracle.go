@@ -7,13 +7,14 @@
 // Note:
 // 1. It needs manually import: _ "github.com/mattn/go-oci8"
 // 2. It does not support Save/Replace features.
-// 3. It does not support LastInsertId.
+// 3. It implements LastInsertId using RETURNING INTO clause for the inserting of Model.
 
 package gdb
 
 import (
 	"database/sql"
 	"fmt"
+	"github.com/gogf/gf/container/gvar"
 	"github.com/gogf/gf/errors/gerror"
 	"github.com/gogf/gf/internal/intlog"
 	"github.com/gogf/gf/text/gregex"
@@ -107,9 +108,50 @@ func (d *DriverOracle) HandleSqlBeforeCommit(link Link, sql string, args []inter
 	return
 }
 
+// GetSavePointSql returns the sql statement of savepoint operation for oracle.
+// Note that oracle does not support releasing a savepoint, it returns empty string for that.
+func (d *DriverOracle) GetSavePointSql(operation int, name string) string {
+	switch operation {
+	case savePointOperationCreate:
+		return "SAVEPOINT " + name
+	case savePointOperationRollback:
+		return "ROLLBACK TO SAVEPOINT " + name
+	}
+	return ""
+}
+
+// GetCteKeyword returns empty string as the common table expressions are not supported for oracle.
+func (d *DriverOracle) GetCteKeyword(recursive bool) string {
+	return ""
+}
+
+// isDateWithTime returns true as the DATE type of oracle also stores the time part down to seconds,
+// so the automatically maintained time of DATE fields is written with the time part.
+func (d *DriverOracle) isDateWithTime() bool {
+	return true
+}
+
+// IsRetryableError checks whether the error is a deadlock or serialization error of oracle,
+// which can be resolved by re-running the transaction.
+func (d *DriverOracle) IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	// ORA-00060: deadlock detected, ORA-08177: can't serialize access for this transaction.
+	s := err.Error()
+	return gstr.Contains(s, "ORA-00060") || gstr.Contains(s, "ORA-08177")
+}
+
 // parseSql does some replacement of the sql before commits it to underlying driver,
 // for support of oracle server.
+//
+// The sub query sql, including the select statements of union, is parsed separately.
 func (d *DriverOracle) parseSql(sql string) string {
+	return parseSqlWithSubQuery(sql, d.doParseSql)
+}
+
+// doParseSql parses the sql statement whose sub queries are replaced with placeholders.
+func (d *DriverOracle) doParseSql(sql string) string {
 	var (
 		patten      = `^\s*(?i)(SELECT)|(LIMIT\s*(\d+)\s*,{0,1}\s*(\d*))`
 		allMatch, _ = gregex.MatchAllString(patten, sql)
@@ -200,8 +242,13 @@ SELECT
 	CASE DATA_TYPE  
 	WHEN 'NUMBER' THEN DATA_TYPE||'('||DATA_PRECISION||','||DATA_SCALE||')' 
 	WHEN 'FLOAT' THEN DATA_TYPE||'('||DATA_PRECISION||','||DATA_SCALE||')' 
-	ELSE DATA_TYPE||'('||DATA_LENGTH||')' END AS TYPE  
-FROM USER_TAB_COLUMNS WHERE TABLE_NAME = '%s' ORDER BY COLUMN_ID`,
+	ELSE DATA_TYPE||'('||DATA_LENGTH||')' END AS TYPE,
+	CASE WHEN EXISTS(
+		SELECT 1 FROM USER_CONSTRAINTS UC, USER_CONS_COLUMNS UCC 
+		WHERE UC.CONSTRAINT_NAME = UCC.CONSTRAINT_NAME AND UC.CONSTRAINT_TYPE = 'P' 
+		AND UC.TABLE_NAME = C.TABLE_NAME AND UCC.COLUMN_NAME = C.COLUMN_NAME
+	) THEN 'PRI' END AS COLUMN_KEY 
+FROM USER_TAB_COLUMNS C WHERE TABLE_NAME = '%s' ORDER BY COLUMN_ID`,
 				strings.ToUpper(table),
 			)
 			structureSql, _ = gregex.ReplaceString(`[\n\r\s]+`, " ", gstr.Trim(structureSql))
@@ -215,6 +262,7 @@ FROM USER_TAB_COLUMNS WHERE TABLE_NAME = '%s' ORDER BY COLUMN_ID`,
 					Index: i,
 					Name:  strings.ToLower(m["FIELD"].String()),
 					Type:  strings.ToLower(m["TYPE"].String()),
+					Key:   m["COLUMN_KEY"].String(),
 				}
 			}
 			return fields, nil
@@ -254,6 +302,7 @@ func (d *DriverOracle) getTableUniqueIndex(table string) (fields map[string]map[
 }
 
 func (d *DriverOracle) DoInsert(link Link, table string, data interface{}, option int, batch ...int) (result sql.Result, err error) {
+	insertOption := d.getDoInsertOption(option, batch...)
 	var (
 		fields  []string
 		values  []string
@@ -281,7 +330,7 @@ func (d *DriverOracle) DoInsert(link Link, table string, data interface{}, optio
 		indexMap    = make(map[string]string)
 		indexExists = false
 	)
-	if option != insertOptionDefault {
+	if insertOption.InsertOption != insertOptionDefault {
 		index, err := d.getTableUniqueIndex(table)
 		if err != nil {
 			return nil, err
@@ -303,12 +352,17 @@ func (d *DriverOracle) DoInsert(link Link, table string, data interface{}, optio
 		onStr     = make([]string, 0)
 		updateStr = make([]string, 0)
 	)
+	// Save操作数据冲突时不更新OnDuplicateEx中的字段。
+	excludes := make([]string, len(insertOption.OnDuplicateEx))
+	for i, v := range insertOption.OnDuplicateEx {
+		excludes[i] = strings.ToUpper(v)
+	}
 	charL, charR := d.DB.GetChars()
 	for k, v := range dataMap {
 		k = strings.ToUpper(k)
 
 		// 操作类型为REPLACE/SAVE时且存在唯一索引才使用merge，否则使用insert
-		if (option == insertOptionReplace || option == insertOptionSave) && indexExists {
+		if (insertOption.InsertOption == insertOptionReplace || insertOption.InsertOption == insertOptionSave) && indexExists {
 			fields = append(fields, tableAlias1+"."+charL+k+charR)
 			values = append(values, tableAlias2+"."+charL+k+charR)
 			params = append(params, v)
@@ -316,7 +370,7 @@ func (d *DriverOracle) DoInsert(link Link, table string, data interface{}, optio
 			//m erge中的on子句中由唯一索引组成, update子句中不含唯一索引
 			if _, ok := indexMap[k]; ok {
 				onStr = append(onStr, fmt.Sprintf("%s.%s = %s.%s ", tableAlias1, k, tableAlias2, k))
-			} else {
+			} else if insertOption.InsertOption != insertOptionSave || !gstr.InArray(excludes, k) {
 				updateStr = append(updateStr, fmt.Sprintf("%s.%s = %s.%s ", tableAlias1, k, tableAlias2, k))
 			}
 		} else {
@@ -332,8 +386,11 @@ func (d *DriverOracle) DoInsert(link Link, table string, data interface{}, optio
 		}
 	}
 
-	if indexExists && option != insertOptionDefault {
-		switch option {
+	if indexExists && insertOption.InsertOption != insertOptionDefault {
+		if len(insertOption.Returning) > 0 {
+			return nil, gerror.New("returning inserted records is not supported by oracle for Save/Replace/InsertIgnore operations")
+		}
+		switch insertOption.InsertOption {
 		case
 			insertOptionReplace,
 			insertOptionSave:
@@ -354,16 +411,78 @@ func (d *DriverOracle) DoInsert(link Link, table string, data interface{}, optio
 		}
 	}
 
-	return d.DB.DoExec(
-		link,
-		fmt.Sprintf(
-			"INSERT INTO %s(%s) VALUES(%s)",
-			table, strings.Join(fields, ","), strings.Join(values, ","),
-		),
-		params...)
+	sqlStr := fmt.Sprintf(
+		"INSERT INTO %s(%s) VALUES(%s)",
+		table, strings.Join(fields, ","), strings.Join(values, ","),
+	)
+	if returningColumns := d.getReturningColumns(insertOption); len(returningColumns) > 0 {
+		return d.doInsertReturning(link, sqlStr, params, returningColumns, insertOption.PrimaryKey)
+	}
+	return d.DB.DoExec(link, sqlStr, params...)
+}
+
+// FormatReturning returns the RETURNING INTO clause for oracle, the returned values are bound to
+// the output parameters which are appended by DoInsert.
+func (d *DriverOracle) FormatReturning(columns []string) (output string, returning string, err error) {
+	if len(columns) == 0 {
+		return "", "", nil
+	}
+	var (
+		upperColumns = make([]string, len(columns))
+		holders      = make([]string, len(columns))
+	)
+	for k, v := range columns {
+		if v == "*" {
+			return "", "", gerror.New(`returning all columns using "*" is not supported by oracle`)
+		}
+		upperColumns[k] = strings.ToUpper(v)
+		holders[k] = "?"
+	}
+	return "", fmt.Sprintf(
+		"RETURNING %s INTO %s",
+		d.formatReturningColumns(upperColumns, ""), strings.Join(holders, ","),
+	), nil
+}
+
+// IsLastInsertIdSupported returns false as the LastInsertId is not supported by oracle drivers,
+// the inserted primary key is retrieved using the RETURNING INTO clause instead.
+func (d *DriverOracle) IsLastInsertIdSupported() bool {
+	return false
+}
+
+// doInsertReturning executes the single record inserting statement <sqlStr> with RETURNING INTO clause,
+// which binds the returned values of <columns> to output parameters.
+func (d *DriverOracle) doInsertReturning(link Link, sqlStr string, params []interface{}, columns []string, primaryKey string) (sql.Result, error) {
+	_, returningStr, err := d.DB.FormatReturning(columns)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*string, len(columns))
+	for k := range columns {
+		values[k] = new(string)
+		params = append(params, sql.Out{Dest: values[k]})
+	}
+	r, err := d.DB.DoExec(link, sqlStr+" "+returningStr, params...)
+	if err != nil {
+		return r, err
+	}
+	result := &SqlResult{
+		result:      r,
+		insertIdKey: primaryKey,
+	}
+	if d.DB.GetDryRun() {
+		return result, nil
+	}
+	record := make(Record, len(columns))
+	for k, v := range columns {
+		record[strings.ToUpper(v)] = gvar.New(*values[k])
+	}
+	result.returning = Result{record}
+	return result, nil
 }
 
 func (d *DriverOracle) DoBatchInsert(link Link, table string, list interface{}, option int, batch ...int) (result sql.Result, err error) {
+	insertOption := d.getDoInsertOption(option, batch...)
 	var (
 		keys   []string
 		values []string
@@ -422,7 +541,9 @@ func (d *DriverOracle) DoBatchInsert(link Link, table string, list interface{},
 		keyStr         = charL + strings.Join(keys, charL+","+charR) + charR
 		valueHolderStr = strings.Join(holders, ",")
 	)
-	if option != insertOptionDefault {
+	// INSERT ALL does not support RETURNING INTO clause, so the records are inserted one by one
+	// if the returning columns are explicitly specified, the primary key is not returned for batch inserting.
+	if insertOption.InsertOption != insertOptionDefault || len(insertOption.Returning) > 0 {
 		for _, v := range listMap {
 			r, err := d.DB.DoInsert(link, table, v, option, 1)
 			if err != nil {
@@ -435,14 +556,15 @@ func (d *DriverOracle) DoBatchInsert(link Link, table string, list interface{},
 				batchResult.result = r
 				batchResult.affected += n
 			}
+			if v, ok := r.(*SqlResult); ok {
+				batchResult.insertIdKey = v.insertIdKey
+				batchResult.returning = append(batchResult.returning, v.returning...)
+			}
 		}
 		return batchResult, nil
 	}
 
-	batchNum := defaultBatchNumber
-	if len(batch) > 0 {
-		batchNum = batch[0]
-	}
+	batchNum := insertOption.BatchCount
 	// Format "INSERT...INTO..." statement.
 	intoStr := make([]string, 0)
 	for i := 0; i < len(listMap); i++ {
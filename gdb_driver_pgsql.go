@@ -6,8 +6,8 @@
 //
 // Note:
 // 1. It needs manually import: _ "github.com/lib/pq"
-// 2. It does not support Save/Replace features.
-// 3. It does not support LastInsertId.
+// 2. It implements Save/Replace/InsertIgnore features using ON CONFLICT clause.
+// 3. It implements LastInsertId using RETURNING clause for the inserting of Model.
 
 package gdb
 
@@ -86,6 +86,40 @@ func (d *DriverPgsql) HandleSqlBeforeCommit(link Link, sql string, args []interf
 	return sql, args
 }
 
+// IsRetryableError checks whether the error is a serialization failure or deadlock error of pgsql,
+// which can be resolved by re-running the transaction.
+// It checks the SQLSTATE of the error, which is supported by drivers like lib/pq and pgx.
+func (d *DriverPgsql) IsRetryableError(err error) bool {
+	if e, ok := err.(interface{ SQLState() string }); ok {
+		// 40001: serialization_failure, 40P01: deadlock_detected.
+		switch e.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+	}
+	return false
+}
+
+// FormatUpsert returns the insert operation and the ON CONFLICT clause for pgsql,
+// which translates Save/Replace to DO UPDATE and InsertIgnore to DO NOTHING.
+func (d *DriverPgsql) FormatUpsert(table string, columns []string, option DoInsertOption) (operation string, upsert string, err error) {
+	return d.formatOnConflictUpsert(table, columns, option)
+}
+
+// FormatReturning returns the RETURNING clause for pgsql.
+func (d *DriverPgsql) FormatReturning(columns []string) (output string, returning string, err error) {
+	if len(columns) == 0 {
+		return "", "", nil
+	}
+	return "", "RETURNING " + d.formatReturningColumns(columns, ""), nil
+}
+
+// IsLastInsertIdSupported returns false as the LastInsertId is not supported by pgsql drivers,
+// the inserted primary key is retrieved using the RETURNING clause instead.
+func (d *DriverPgsql) IsLastInsertIdSupported() bool {
+	return false
+}
+
 // Tables retrieves and returns the tables of current schema.
 // It's mainly used in cli tool chain for automatically generating the models.
 func (d *DriverPgsql) Tables(schema ...string) (tables []string, err error) {
@@ -134,7 +168,11 @@ func (d *DriverPgsql) TableFields(table string, schema ...string) (fields map[st
 				return nil, err
 			}
 			structureSql := fmt.Sprintf(`
-SELECT a.attname AS field, t.typname AS type FROM pg_class c, pg_attribute a 
+SELECT a.attname AS field, t.typname AS type,
+CASE WHEN EXISTS(SELECT 1 FROM pg_index i WHERE i.indrelid = c.oid AND i.indisprimary AND a.attnum = ANY(i.indkey)) THEN 'PRI'
+WHEN EXISTS(SELECT 1 FROM pg_index i WHERE i.indrelid = c.oid AND i.indisunique AND i.indnatts = 1 AND a.attnum = ANY(i.indkey)) THEN 'UNI'
+ELSE '' END AS key
+FROM pg_class c, pg_attribute a 
 LEFT OUTER JOIN pg_description b ON a.attrelid=b.objoid AND a.attnum = b.objsubid,pg_type t
 WHERE c.relname = '%s' and a.attnum > 0 and a.attrelid = c.oid and a.atttypid = t.oid 
 ORDER BY a.attnum`,
@@ -152,6 +190,7 @@ ORDER BY a.attnum`,
 					Index: i,
 					Name:  m["field"].String(),
 					Type:  m["type"].String(),
+					Key:   m["key"].String(),
 				}
 			}
 			return fields, nil
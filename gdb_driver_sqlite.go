@@ -6,7 +6,7 @@
 //
 // Note:
 // 1. It needs manually import: _ "github.com/mattn/go-sqlite3"
-// 2. It does not support Save/Replace features.
+// 2. It implements Save/Replace/InsertIgnore features using ON CONFLICT clause, which needs sqlite 3.24.0+.
 
 package gdb
 
@@ -17,6 +17,7 @@ import (
 	"github.com/gogf/gf/internal/intlog"
 	"github.com/gogf/gf/os/gfile"
 	"github.com/gogf/gf/text/gstr"
+	"reflect"
 	"strings"
 )
 
@@ -65,12 +66,60 @@ func (d *DriverSqlite) GetChars() (charLeft string, charRight string) {
 }
 
 // HandleSqlBeforeCommit deals with the sql string before commits it to underlying sql driver.
-// TODO 需要增加对Save方法的支持，可使用正则来实现替换，
-// TODO 将ON DUPLICATE KEY UPDATE触发器修改为两条SQL语句(INSERT OR IGNORE & UPDATE)
 func (d *DriverSqlite) HandleSqlBeforeCommit(link Link, sql string, args []interface{}) (string, []interface{}) {
 	return sql, args
 }
 
+// IsRetryableError checks whether the error is a database busy or locked error of sqlite,
+// which can be resolved by re-running the transaction.
+//
+// The sqlite driver package is not imported here as it needs cgo, so the error code is read
+// from the field "Code" of its error struct using reflection.
+func (d *DriverSqlite) IsRetryableError(err error) bool {
+	reflectValue := reflect.ValueOf(err)
+	if reflectValue.Kind() == reflect.Ptr {
+		reflectValue = reflectValue.Elem()
+	}
+	if reflectValue.Kind() != reflect.Struct {
+		return false
+	}
+	code := reflectValue.FieldByName("Code")
+	if !code.IsValid() || code.Kind() != reflect.Int {
+		return false
+	}
+	// 5: SQLITE_BUSY, 6: SQLITE_LOCKED.
+	switch code.Int() {
+	case 5, 6:
+		return true
+	}
+	return false
+}
+
+// GetUnionSql returns the union sql statement for sqlite.
+// Sqlite does not support parentheses around the compound select statements,
+// so each select statement is wrapped as a sub query for its own ORDER BY and LIMIT clauses.
+func (d *DriverSqlite) GetUnionSql(unionType string, sqlList []string) string {
+	array := make([]string, len(sqlList))
+	for k, v := range sqlList {
+		array[k] = "SELECT * FROM (" + v + ")"
+	}
+	return strings.Join(array, " "+unionType+" ")
+}
+
+// FormatUpsert returns the insert operation and the ON CONFLICT clause for sqlite,
+// which translates Save/Replace to DO UPDATE and InsertIgnore to DO NOTHING.
+func (d *DriverSqlite) FormatUpsert(table string, columns []string, option DoInsertOption) (operation string, upsert string, err error) {
+	return d.formatOnConflictUpsert(table, columns, option)
+}
+
+// FormatReturning returns the RETURNING clause for sqlite, which needs sqlite 3.35.0+.
+func (d *DriverSqlite) FormatReturning(columns []string) (output string, returning string, err error) {
+	if len(columns) == 0 {
+		return "", "", nil
+	}
+	return "", "RETURNING " + d.formatReturningColumns(columns, ""), nil
+}
+
 // Tables retrieves and returns the tables of current schema.
 // It's mainly used in cli tool chain for automatically generating the models.
 func (d *DriverSqlite) Tables(schema ...string) (tables []string, err error) {
@@ -107,8 +156,9 @@ func (d *DriverSqlite) TableFields(table string, schema ...string) (fields map[s
 		fmt.Sprintf(`sqlite_table_fields_%s_%s@group:%s`, table, checkSchema, d.GetGroup()),
 		func() (interface{}, error) {
 			var (
-				result Result
-				link   *sql.DB
+				result  Result
+				indexes Result
+				link    *sql.DB
 			)
 			link, err = d.DB.GetSlave(checkSchema)
 			if err != nil {
@@ -120,10 +170,35 @@ func (d *DriverSqlite) TableFields(table string, schema ...string) (fields map[s
 			}
 			fields = make(map[string]*TableField)
 			for i, m := range result {
+				key := ""
+				if m["pk"].Int() > 0 {
+					key = "PRI"
+				}
 				fields[strings.ToLower(m["name"].String())] = &TableField{
 					Index: i,
 					Name:  strings.ToLower(m["name"].String()),
 					Type:  strings.ToLower(m["type"].String()),
+					Key:   key,
+				}
+			}
+			// Single column unique indexes.
+			indexes, err = d.DB.DoGetAll(link, fmt.Sprintf(`PRAGMA INDEX_LIST(%s)`, table))
+			if err != nil {
+				return nil, err
+			}
+			for _, index := range indexes {
+				if !index["unique"].Bool() {
+					continue
+				}
+				columns, err := d.DB.DoGetAll(link, fmt.Sprintf(`PRAGMA INDEX_INFO(%s)`, d.QuoteWord(index["name"].String())))
+				if err != nil {
+					return nil, err
+				}
+				if len(columns) != 1 {
+					continue
+				}
+				if field, ok := fields[strings.ToLower(columns[0]["name"].String())]; ok && field.Key == "" {
+					field.Key = "UNI"
 				}
 			}
 			return fields, nil
@@ -48,15 +48,23 @@ type apiMapStrAny interface {
 }
 
 const (
-	OrmTagForStruct  = "orm"
-	OrmTagForUnique  = "unique"
-	OrmTagForPrimary = "primary"
+	OrmTagForStruct   = "orm"
+	OrmTagForUnique   = "unique"
+	OrmTagForPrimary  = "primary"
+	OrmTagForWith     = "with"
+	OrmTagForTable    = "table"
+	OrmTagForPivot    = "pivot"
+	OrmTagForPivotKey = "pivot_key"
+	OrmTagForVersion  = "version"
 )
 
 var (
 	// quoteWordReg 是用于单词检查的正则表达式对象。
 	quoteWordReg = regexp.MustCompile(`^[a-zA-Z0-9\-_]+$`)
 
+	// subQueryHolderReg 是转换sql时子查询占位符的正则表达式对象，占位符格式为“GF_SUB_QUERY_序号”。
+	subQueryHolderReg = regexp.MustCompile(`GF_SUB_QUERY_(\d+)`)
+
 	// orm字段映射的结构转换的优先级标记。
 	structTagPriority = append([]string{OrmTagForStruct}, gconv.StructTagPriority...)
 )
@@ -111,6 +119,11 @@ func ConvertDataForTableRecord(value interface{}) map[string]interface{} {
 		data    = DataToMapDeep(value)
 	)
 	for k, v := range data {
+		// The relation attributes for Model.With are not table fields.
+		if gstr.HasPrefix(k, OrmTagForWith+":") {
+			delete(data, k)
+			continue
+		}
 		rvValue = reflect.ValueOf(v)
 		rvKind = rvValue.Kind()
 		for rvKind == reflect.Ptr {
@@ -429,6 +442,20 @@ func formatWhere(db DB, where interface{}, args []interface{}, omitEmpty bool) (
 		}
 
 	case reflect.Struct:
+		// 条件构造器，构造嵌套的条件组。
+		var builder *WhereBuilder
+		switch v := where.(type) {
+		case *WhereBuilder:
+			builder = v
+		case WhereBuilder:
+			builder = &v
+		}
+		if builder != nil {
+			builderWhere, builderArgs := builder.build(db, omitEmpty)
+			buffer.WriteString(builderWhere)
+			newArgs = append(newArgs, builderArgs...)
+			break
+		}
 		// 如果<where>struct实现apiterator接口，那么它将使用其Iterate函数来迭代其键值对。
 		// 例如，ListMap和TreeMap是有序map，它们实现了apiterator接口，并且对于where条件是索引友好的。
 		if iterator, ok := where.(apiIterator); ok {
@@ -679,6 +706,16 @@ func handleArguments(sql string, args []interface{}) (newSql string, newArgs []i
 					newArgs = append(newArgs, v.String())
 					continue
 
+				// Sub query, it replaces the '?' holder with the sub query sql,
+				// and merges the sub query arguments in place.
+				// Eg: Where("id IN(?)", db.Model("user").Fields("id").Where("status", 1))
+				case *Model:
+					subSql, subArgs := v.getSelectSqlAndArgs(false)
+					newSql = replaceHolderWithSubQuery(newSql, index+insertHolderCount, subSql)
+					insertHolderCount += len(subArgs) - 1
+					newArgs = append(newArgs, subArgs...)
+					continue
+
 				default:
 					// It converts the struct to string in default
 					// if it has implemented the String interface.
@@ -697,10 +734,138 @@ func handleArguments(sql string, args []interface{}) (newSql string, newArgs []i
 	return
 }
 
+// replaceHolderWithSubQuery 将<sql>中第<holderIndex>个（从0开始）“?”占位符替换为子查询语句<subSql>，
+// 如果占位符没有被括号包裹，子查询语句将自动使用括号包裹。
+func replaceHolderWithSubQuery(sql string, holderIndex int, subSql string) string {
+	counter := -1
+	for i := 0; i < len(sql); i++ {
+		if sql[i] != '?' {
+			continue
+		}
+		counter++
+		if counter != holderIndex {
+			continue
+		}
+		var (
+			left  = strings.TrimRight(sql[:i], " ")
+			right = strings.TrimLeft(sql[i+1:], " ")
+		)
+		if !(strings.HasSuffix(left, "(") && strings.HasPrefix(right, ")")) {
+			subSql = "(" + subSql + ")"
+		}
+		return sql[:i] + subSql + sql[i+1:]
+	}
+	return sql
+}
+
+// replaceSubQuery 查找<sql>中所有使用括号包裹的子查询语句，并使用<replace>的返回值替换括号中的子查询语句。
+// 嵌套的子查询作为外层子查询语句的一部分传递给<replace>，括号不匹配时剩余的语句保持不变。
+//
+// Eg: replaceSubQuery("SELECT * FROM (SELECT * FROM user) AS u", f) -> "SELECT * FROM (f("SELECT * FROM user")) AS u"
+func replaceSubQuery(sql string, replace func(subQuery string) string) string {
+	if !gstr.Contains(sql, "(") {
+		return sql
+	}
+	var (
+		length = len(sql)
+		buffer = bytes.NewBuffer(nil)
+	)
+	for i := 0; i < length; i++ {
+		if sql[i] != '(' || !isSubQuery(sql[i:]) {
+			buffer.WriteByte(sql[i])
+			continue
+		}
+		// Find the matched close parenthesis.
+		level, end := 0, -1
+		for j := i; j < length && end == -1; j++ {
+			switch sql[j] {
+			case '(':
+				level++
+			case ')':
+				level--
+				if level == 0 {
+					end = j
+				}
+			}
+		}
+		if end == -1 {
+			buffer.WriteString(sql[i:])
+			break
+		}
+		buffer.WriteString("(" + replace(sql[i+1:end]) + ")")
+		i = end
+	}
+	return buffer.String()
+}
+
+// parseSqlWithSubQuery 使用<parse>转换<sql>，其中的子查询语句（包括组合查询的各个查询语句以及公共表表达式）使用<parse>单独转换。
+// 转换当前语句时子查询使用占位符替代，避免子查询中的LIMIT/ORDER BY等语句影响当前语句的转换。
+func parseSqlWithSubQuery(sql string, parse func(sql string) string) string {
+	var subQueries []string
+	sql = replaceSubQuery(sql, func(subQuery string) string {
+		subQueries = append(subQueries, parseSqlWithSubQuery(subQuery, parse))
+		return fmt.Sprintf(`GF_SUB_QUERY_%d`, len(subQueries)-1)
+	})
+	// 公共表表达式（WITH ... AS (...)）保持不变，只转换其后的查询语句。
+	prefix := ""
+	if gregex.IsMatchString(`^\s*(?i)WITH\s`, sql) {
+		if p := gstr.PosI(sql, "SELECT "); p > 0 {
+			prefix, sql = sql[:p], sql[p:]
+		}
+	}
+	sql = prefix + parse(sql)
+	if len(subQueries) == 0 {
+		return sql
+	}
+	return subQueryHolderReg.ReplaceAllStringFunc(sql, func(s string) string {
+		index := gconv.Int(subQueryHolderReg.FindStringSubmatch(s)[1])
+		if index < len(subQueries) {
+			return subQueries[index]
+		}
+		return s
+	})
+}
+
+// sqlError 是执行SQL失败时返回的错误，错误信息由底层驱动的错误信息及执行的SQL组成，
+// 同时保留了底层驱动的原始错误，可以通过gerror.Cause或者errors.Unwrap获取。
+type sqlError struct {
+	error       // 包含错误信息及调用堆栈的错误对象。
+	cause error // 底层驱动的原始错误。
+}
+
+// Cause 返回底层驱动的原始错误。
+func (e *sqlError) Cause() error {
+	return gerror.Cause(e.cause)
+}
+
+// Unwrap 返回底层驱动的原始错误，用于errors.Is/errors.As。
+func (e *sqlError) Unwrap() error {
+	return e.cause
+}
+
+// Stack 返回创建错误时的调用堆栈。
+func (e *sqlError) Stack() string {
+	return gerror.Stack(e.error)
+}
+
+// Format 按照fmt.Formatter接口格式化错误，例如%+v时输出错误信息及调用堆栈。
+func (e *sqlError) Format(s fmt.State, verb rune) {
+	if f, ok := e.error.(fmt.Formatter); ok {
+		f.Format(s, verb)
+		return
+	}
+	_, _ = s.Write([]byte(e.error.Error()))
+}
+
 // formatError 自定义并返回SQL错误。
+//
+// 返回的错误保留了底层驱动的原始错误，可以通过gerror.Cause获取，例如用于判断死锁等可重试的错误。
 func formatError(err error, sql string, args ...interface{}) error {
 	if err != nil && err != ErrNoRows {
-		return gerror.New(fmt.Sprintf("%s, %s\n", err.Error(), FormatSqlWithArgs(sql, args)))
+		return &sqlError{
+			error: gerror.New(fmt.Sprintf("%s, %s\n", err.Error(), FormatSqlWithArgs(sql, args))),
+			cause: err,
+		}
 	}
 	return err
 }
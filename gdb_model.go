@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"github.com/gogf/gf/text/gregex"
+	"reflect"
 	"time"
 
 	"github.com/gogf/gf/text/gstr"
+	"github.com/gogf/gf/util/gconv"
 )
 
 // Model 是ORM的DAO
@@ -15,10 +17,16 @@ type Model struct {
 	tx            *TX            // 底层事务接口。
 	schema        string         // 自定义数据库架构。
 	linkType      int            // 主设备或从设备上的操作标记。
+	link          Link           // 固定使用的数据库连接，为nil时根据linkType获取。
 	tablesInit    string         // 模型初始化时的表名。
 	tables        string         // 操作表名，可以是多个表名和别名，如：“user”、“user u”、“user u、user\u”。
+	tableRefs     []tableRef     // 添加了表前缀的表名在tables中的位置，用于还原引用公共表表达式的表名。
+	tablesArgs    []interface{}  // 表名及联表中子查询的参数。
+	alias         string         // 通过As设置的当前表别名。
 	fields        string         // 操作字段，使用字符'，'连接的多个字段。
 	fieldsEx      string         // 排除的操作字段，使用字符'，'连接的多个字段。
+	fieldsArgs    []interface{}  // 操作字段中子查询的参数。
+	distinct      bool           // 用于“select distinct ...”语句。
 	extraArgs     []interface{}  // sql的额外自定义参数。
 	whereHolder   []*whereHolder // where操作的条件字符串。
 	groupBy       string         // 用于“group by”语句。
@@ -29,14 +37,26 @@ type Model struct {
 	option        int            // Option 额外的操作功能。
 	offset        int            // Offset 一些数据库语法的语句。
 	data          interface{}    // Data 对于操作，可以是map/[]map/struct/*struct/string等类型。
+	entities      []interface{}  // 通过Data传入的原始实体对象，用于执行实体实现的钩子方法。
 	batch         int            // Batch 批量插入/替换/保存操作的数量。
+	onConflict    []string       // 插入冲突时检测的字段，用于pgsql/sqlite的ON CONFLICT子句。
+	onDuplicate   interface{}    // 保存操作冲突时更新的字段，可以是string/[]string/map等类型。
+	onDuplicateEx interface{}    // 保存操作冲突时不更新的字段，可以是string/[]string等类型。
+	returning     []string       // 写入后返回的字段，用于pgsql/sqlite的RETURNING等子句。
 	filter        bool           // 根据表的字段过滤数据和where键值对。
 	lockInfo      string         // 锁定更新或共享锁定。
 	cacheEnabled  bool           // 启用sql结果缓存功能。
 	cacheDuration time.Duration  // 缓存TTL持续时间。
 	cacheName     string         // 自定义操作的缓存名称。
 	unscoped      bool           // 在选择/删除操作时禁用软删除功能。
+	onlyTrashed   bool           // 查询时只返回已被软删除的记录。
 	safe          bool           // 如果为true，则在操作完成时克隆并返回一个新的模型对象；否则更改当前模型的属性。
+	withArray     []interface{}  // 需要预加载的关联属性对象。
+	withAll       bool           // 预加载所有关联属性。
+	withPath      []reflect.Type // 当前预加载路径上的结构体类型，用于跳过循环关联。
+	ctes          []*cteHolder   // 查询语句前的公共表表达式。
+	cteNames      []string       // 可以引用的公共表表达式名称，这些名称不是真实的表，不添加表前缀也不检测软删除字段。
+	err           error          // 链式操作中产生的错误，在执行查询、更新、删除操作时返回。
 }
 
 // whereHolder 是条件准备的持有者。
@@ -65,21 +85,33 @@ const (
 //    Table("user, user_detail")
 //    Table("user u, user_detail ud")
 // 2. Table name with alias: Table("user", "u")
-func (c *Core) Table(table ...string) *Model {
-	tables := ""
-	if len(table) > 1 {
-		tables = fmt.Sprintf(
-			`%s AS %s`, c.DB.QuotePrefixTableName(table[0]), c.DB.QuoteWord(table[1]),
-		)
-	} else if len(table) == 1 {
-		tables = c.DB.QuotePrefixTableName(table[0])
-	} else {
+// 3. Sub-query with alias: Table(db.Model("user").Where("status", 1), "u")
+func (c *Core) Table(tableNameOrSubQuery ...interface{}) *Model {
+	var (
+		tables     = ""
+		tablesArgs []interface{}
+		tableRefs  []tableRef
+	)
+	if len(tableNameOrSubQuery) == 0 {
 		panic("表不能为空")
 	}
+	if subModel, ok := tableNameOrSubQuery[0].(*Model); ok {
+		tables, tablesArgs = subModel.getSubQuery()
+	} else {
+		tables = c.DB.QuotePrefixTableName(gconv.String(tableNameOrSubQuery[0]))
+		if ref, ok := newTableRef(c.DB, gconv.String(tableNameOrSubQuery[0]), tables, 0); ok {
+			tableRefs = append(tableRefs, ref)
+		}
+	}
+	if len(tableNameOrSubQuery) > 1 {
+		tables = fmt.Sprintf(`%s AS %s`, tables, c.DB.QuoteWord(gconv.String(tableNameOrSubQuery[1])))
+	}
 	return &Model{
 		db:         c.DB,
 		tablesInit: tables,
 		tables:     tables,
+		tableRefs:  tableRefs,
+		tablesArgs: tablesArgs,
 		fields:     "*",
 		start:      -1,
 		offset:     -1,
@@ -88,21 +120,21 @@ func (c *Core) Table(table ...string) *Model {
 }
 
 // Model 是Core.Table的别名。
-func (c *Core) Model(table ...string) *Model {
-	return c.DB.Table(table...)
+func (c *Core) Model(tableNameOrSubQuery ...interface{}) *Model {
+	return c.DB.Table(tableNameOrSubQuery...)
 }
 
 // Table 对事务进行操作，返回一个 *gdb.Model对象
-func (tx *TX) Table(table ...string) *Model {
-	model := tx.db.Table(table...)
+func (tx *TX) Table(tableNameOrSubQuery ...interface{}) *Model {
+	model := tx.db.Table(tableNameOrSubQuery...)
 	model.db = tx.db
 	model.tx = tx
 	return model
 }
 
 // Model tx.Table的别名。
-func (tx *TX) Model(table ...string) *Model {
-	return tx.Table(table...)
+func (tx *TX) Model(tableNameOrSubQuery ...interface{}) *Model {
+	return tx.Table(tableNameOrSubQuery...)
 }
 
 // Ctx 设置当前操作的上下文。
@@ -128,6 +160,7 @@ func (m *Model) As(as string) *Model {
 		} else {
 			// For base table.
 			model.tables = gstr.TrimRight(model.tables) + " AS " + as
+			model.alias = as
 		}
 		return model
 	}
@@ -176,6 +209,30 @@ func (m *Model) Clone() *Model {
 		newModel.whereHolder = make([]*whereHolder, n)
 		copy(newModel.whereHolder, m.whereHolder)
 	}
+	if n := len(m.tablesArgs); n > 0 {
+		newModel.tablesArgs = make([]interface{}, n)
+		copy(newModel.tablesArgs, m.tablesArgs)
+	}
+	if n := len(m.fieldsArgs); n > 0 {
+		newModel.fieldsArgs = make([]interface{}, n)
+		copy(newModel.fieldsArgs, m.fieldsArgs)
+	}
+	if n := len(m.withArray); n > 0 {
+		newModel.withArray = make([]interface{}, n)
+		copy(newModel.withArray, m.withArray)
+	}
+	if n := len(m.ctes); n > 0 {
+		newModel.ctes = make([]*cteHolder, n)
+		copy(newModel.ctes, m.ctes)
+	}
+	if n := len(m.cteNames); n > 0 {
+		newModel.cteNames = make([]string, n)
+		copy(newModel.cteNames, m.cteNames)
+	}
+	if n := len(m.tableRefs); n > 0 {
+		newModel.tableRefs = make([]tableRef, n)
+		copy(newModel.tableRefs, m.tableRefs)
+	}
 	return newModel
 }
 
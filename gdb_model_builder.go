@@ -0,0 +1,94 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"fmt"
+)
+
+// WhereBuilder 是可独立构造的条件构造器，可以作为Model.Where/And/Or以及WhereBuilder自身的条件参数，用于构造嵌套的AND/OR条件组。
+//
+// WhereBuilder是不可变的，每次链式调用都返回一个新的对象，因此可以安全地在多个模型之间复用。
+//
+// Eg:
+//
+// b := gdb.NewWhereBuilder()
+//
+// db.Model("user").Where("status", 1).Where(b.Where("age>?", 18).Or("vip", 1))
+//
+// 等价于: WHERE (`status`=1) AND ((age>18) OR (`vip`=1))
+type WhereBuilder struct {
+	whereHolder []*whereHolder // where操作的条件。
+}
+
+// NewWhereBuilder 创建并返回一个空的条件构造器。
+func NewWhereBuilder() *WhereBuilder {
+	return &WhereBuilder{}
+}
+
+// Where 添加“AND”条件，参数与Model.Where相同，<where>也可以是另一个*WhereBuilder。
+func (b *WhereBuilder) Where(where interface{}, args ...interface{}) *WhereBuilder {
+	return b.append(whereHolderWhere, where, args)
+}
+
+// And 添加“AND”条件，同Where。
+func (b *WhereBuilder) And(where interface{}, args ...interface{}) *WhereBuilder {
+	return b.append(whereHolderAnd, where, args)
+}
+
+// Or 添加“OR”条件，参数与Model.Or相同，<where>也可以是另一个*WhereBuilder。
+func (b *WhereBuilder) Or(where interface{}, args ...interface{}) *WhereBuilder {
+	return b.append(whereHolderOr, where, args)
+}
+
+// append 复制当前构造器并添加新的条件后返回。
+func (b *WhereBuilder) append(operator int, where interface{}, args []interface{}) *WhereBuilder {
+	newBuilder := &WhereBuilder{
+		whereHolder: make([]*whereHolder, len(b.whereHolder), len(b.whereHolder)+1),
+	}
+	copy(newBuilder.whereHolder, b.whereHolder)
+	newBuilder.whereHolder = append(newBuilder.whereHolder, &whereHolder{
+		operator: operator,
+		where:    where,
+		args:     args,
+	})
+	return newBuilder
+}
+
+// Build 使用<db>的语法构造并返回条件语句及其参数，条件语句不包含“WHERE”关键字。
+//
+// 条件按照从左到右的顺序组合，每个条件都使用括号包裹，运算符变化时已组合的条件也会使用括号包裹，
+// 如: Where(a).Or(b).Where(c) 构造为 ((a) OR (b)) AND (c)。
+func (b *WhereBuilder) Build(db DB) (conditionWhere string, conditionArgs []interface{}) {
+	return b.build(db, false)
+}
+
+// build 构造并返回条件语句及其参数，<omitEmpty>指定是否忽略空值的条件。
+func (b *WhereBuilder) build(db DB, omitEmpty bool) (conditionWhere string, conditionArgs []interface{}) {
+	var lastOperator string
+	for _, v := range b.whereHolder {
+		newWhere, newArgs := formatWhere(db, v.where, v.args, omitEmpty)
+		if len(newWhere) == 0 {
+			continue
+		}
+		operator := "AND"
+		if v.operator == whereHolderOr {
+			operator = "OR"
+		}
+		if len(conditionWhere) == 0 {
+			conditionWhere = fmt.Sprintf(`(%s)`, newWhere)
+		} else {
+			if lastOperator != "" && lastOperator != operator {
+				conditionWhere = fmt.Sprintf(`(%s)`, conditionWhere)
+			}
+			conditionWhere = fmt.Sprintf(`%s %s (%s)`, conditionWhere, operator, newWhere)
+			lastOperator = operator
+		}
+		conditionArgs = append(conditionArgs, newArgs...)
+	}
+	return
+}
@@ -32,8 +32,20 @@ func (m *Model) Cache(duration time.Duration, name ...string) *Model {
 }
 
 // checkAndRemoveCache 如果启用了缓存功能，则检查并删除insert/update/delete语句中的缓存。
+//
+// 如果当前模型在事务中执行，那么缓存将在事务提交成功后才会删除，避免其他请求在事务提交前重新缓存旧数据。
 func (m *Model) checkAndRemoveCache() {
 	if m.cacheEnabled && m.cacheDuration < 0 && len(m.cacheName) > 0 {
-		m.db.GetCache().Ctx(m.db.GetCtx()).Remove(m.cacheName)
+		var (
+			cache     = m.db.GetCache().Ctx(m.db.GetCtx())
+			cacheName = m.cacheName
+		)
+		if tx := m.getTX(); tx != nil {
+			tx.OnCommit(func() {
+				cache.Remove(cacheName)
+			})
+			return
+		}
+		cache.Remove(cacheName)
 	}
 }
@@ -0,0 +1,203 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"fmt"
+
+	"github.com/gogf/gf/errors/gerror"
+	"github.com/gogf/gf/text/gstr"
+)
+
+// cteHolder 是公共表表达式(CTE)的持有者。
+type cteHolder struct {
+	name      string // 公共表表达式的名称。
+	anchor    *Model // 公共表表达式的查询模型，递归时为初始查询（锚点）模型。
+	recursive *Model // 递归查询模型，为nil时表示非递归的公共表表达式。
+}
+
+// WithCTE 添加名称为<name>的公共表表达式，其查询语句由<subModel>生成，
+// 公共表表达式会在All/One/Value/Array/Count等查询操作的语句前生成，可以在当前模型中以<name>作为表名引用。
+//
+// 支持mysql(8.0+)/pgsql/sqlite/mssql，其他数据库执行查询时返回错误。
+//
+// Eg:
+//
+// db.Model("active_user").WithCTE("active_user", db.Model("user").Where("status", 1)).All()
+func (m *Model) WithCTE(name string, subModel *Model) *Model {
+	model := m.getModel()
+	model.ctes = append(model.ctes, &cteHolder{
+		name:   name,
+		anchor: subModel,
+	})
+	model.cteNames = append(model.cteNames, name)
+	model.removeCtePrefix([]string{name})
+	return model
+}
+
+// WithRecursiveCTE 添加名称为<name>的递归公共表表达式，其查询语句由初始查询模型<anchor>和递归查询模型<recursive>
+// 使用“UNION ALL”组合生成，<recursive>中可以以<name>作为表名引用上一次递归的结果，其他同WithCTE。
+//
+// Eg:
+//
+// db.Model("tree").WithRecursiveCTE("tree", db.Model("category").Where("id", 1), db.Model("category c").InnerJoin("tree t", "c.parent_id=t.id").Fields("c.*")).All()
+func (m *Model) WithRecursiveCTE(name string, anchor *Model, recursive *Model) *Model {
+	model := m.getModel()
+	model.ctes = append(model.ctes, &cteHolder{
+		name:      name,
+		anchor:    anchor,
+		recursive: recursive,
+	})
+	model.cteNames = append(model.cteNames, name)
+	model.removeCtePrefix([]string{name})
+	return model
+}
+
+// withCteSql 在查询语句<sql>前添加当前模型的公共表表达式，公共表表达式的参数位于<args>之前。
+// 如果当前数据库不支持公共表表达式，则返回错误。
+func (m *Model) withCteSql(sql string, args []interface{}) (string, []interface{}, error) {
+	if len(m.ctes) == 0 {
+		return sql, args, nil
+	}
+	recursive := false
+	for _, v := range m.ctes {
+		if v.recursive != nil {
+			recursive = true
+			break
+		}
+	}
+	keyword := m.db.GetCteKeyword(recursive)
+	if keyword == "" {
+		return "", nil, gerror.Newf(`common table expression is not supported by database type "%s"`, m.db.GetConfig().Type)
+	}
+	var (
+		cteList = make([]string, 0, len(m.ctes))
+		cteArgs = make([]interface{}, 0)
+	)
+	// 公共表表达式只能引用外层以及在其之前定义的公共表表达式，递归查询还可以引用其自身，
+	// 因此公共表表达式中与其同名的表是真实的表。
+	names := make([]string, 0, len(m.cteNames))
+	for _, name := range m.cteNames {
+		if !m.isOwnCteName(name) {
+			names = append(names, name)
+		}
+	}
+	for _, v := range m.ctes {
+		cteSql, holderArgs := v.anchor.withCteNames(names).getSelectSqlAndArgs(false)
+		cteArgs = append(cteArgs, holderArgs...)
+		names = append(names, v.name)
+		if v.recursive != nil {
+			recursiveSql, recursiveArgs := v.recursive.withCteNames(names).getSelectSqlAndArgs(false)
+			cteSql = fmt.Sprintf(`%s %s %s`, cteSql, unionTypeAll, recursiveSql)
+			cteArgs = append(cteArgs, recursiveArgs...)
+		}
+		cteList = append(cteList, fmt.Sprintf(`%s AS (%s)`, m.db.QuoteWord(v.name), cteSql))
+	}
+	sql = fmt.Sprintf(`%s %s %s`, keyword, gstr.Join(cteList, ", "), sql)
+	return sql, append(cteArgs, args...), nil
+}
+
+// withCteNames 返回设置了可引用的公共表表达式名称<names>的模型，用于生成公共表表达式的查询语句。
+func (m *Model) withCteNames(names []string) *Model {
+	model := m.Clone()
+	model.cteNames = append(model.cteNames, names...)
+	model.removeCtePrefix(names)
+	return model
+}
+
+// isOwnCteName 检查<name>是否为当前模型定义的公共表表达式名称。
+func (m *Model) isOwnCteName(name string) bool {
+	for _, v := range m.ctes {
+		if v.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// tableRef 是模型创建及联表时添加了表前缀的表名引用，公共表表达式的名称不是真实的表，
+// 在设置公共表表达式之前创建的引用需要根据其位置还原为不带表前缀的名称。
+type tableRef struct {
+	name   string // 添加表前缀之前的表名。
+	offset int    // 添加了表前缀的表名在模型的tables中的位置。
+}
+
+// newTableRef 检查表名<table>转义后的表名<quoted>是否被添加了表前缀，如果是则返回其位于<offset>的引用。
+// 带有数据库名称的表名以及多个表名不会是公共表表达式的名称，不需要记录。
+func newTableRef(db DB, table, quoted string, offset int) (tableRef, bool) {
+	prefix := db.GetPrefix()
+	if prefix == "" || gstr.ContainsAny(table, ",.") {
+		return tableRef{}, false
+	}
+	var (
+		charL, charR = db.GetChars()
+		array        = gstr.SplitAndTrim(table, " ")
+	)
+	if len(array) == 0 {
+		return tableRef{}, false
+	}
+	name := gstr.Trim(array[0], charL+charR)
+	if gstr.HasPrefix(name, prefix) || !gstr.HasPrefix(quoted, doQuoteWord(prefix+name, charL, charR)) {
+		return tableRef{}, false
+	}
+	return tableRef{name: name, offset: offset}, true
+}
+
+// removeCtePrefix 将模型中引用公共表表达式<names>且被添加了表前缀的表名还原为不带表前缀的名称，
+// 用于在设置公共表表达式之前创建的模型及联表，直接使用带有表前缀的真实表名以及子查询中的表名不受影响。
+func (m *Model) removeCtePrefix(names []string) {
+	if len(m.tableRefs) == 0 || len(names) == 0 {
+		return
+	}
+	var (
+		charL, charR = m.db.GetChars()
+		prefix       = m.db.GetPrefix()
+		refs         = make([]tableRef, 0, len(m.tableRefs))
+	)
+	// 从后往前替换，替换后位于其后的引用的位置随之改变。
+	for i := len(m.tableRefs) - 1; i >= 0; i-- {
+		var (
+			ref    = m.tableRefs[i]
+			quoted = doQuoteWord(prefix+ref.name, charL, charR)
+		)
+		if !gstr.InArray(names, ref.name) || !gstr.HasPrefix(m.tables[ref.offset:], quoted) {
+			refs = append(refs, ref)
+			continue
+		}
+		replaced := m.db.QuoteWord(ref.name)
+		m.tables = m.tables[:ref.offset] + replaced + m.tables[ref.offset+len(quoted):]
+		for j := range refs {
+			refs[j].offset += len(replaced) - len(quoted)
+		}
+	}
+	// 保持引用的顺序。
+	for i, j := 0, len(refs)-1; i < j; i, j = i+1, j-1 {
+		refs[i], refs[j] = refs[j], refs[i]
+	}
+	m.tableRefs = refs
+}
+
+// quoteTableName 转义表名<table>并添加表前缀，可引用的公共表表达式名称不添加表前缀。
+func (m *Model) quoteTableName(table string) string {
+	if len(m.cteNames) > 0 {
+		charL, charR := m.db.GetChars()
+		if array := gstr.SplitAndTrim(table, " "); len(array) > 0 && gstr.InArray(m.cteNames, gstr.Trim(array[0], charL+charR)) {
+			return doHandleTableName(table, "", charL, charR)
+		}
+	}
+	return m.db.QuotePrefixTableName(table)
+}
+
+// isCteTable 检查表名<table>是否为当前模型可引用的公共表表达式名称，<table>可以带有转义字符。
+// 引用公共表表达式的表名不带有表前缀，带有表前缀的同名表是真实的表。
+func (m *Model) isCteTable(table string) bool {
+	if len(m.cteNames) == 0 {
+		return false
+	}
+	charL, charR := m.db.GetChars()
+	return gstr.InArray(m.cteNames, gstr.Trim(table, charL+charR))
+}
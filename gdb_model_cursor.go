@@ -0,0 +1,157 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gogf/gf/container/gvar"
+)
+
+// Cursor 是查询结果的流式迭代器，每次只从数据库读取并转换一条记录，适用于大数据量的导出等场景。
+//
+// Cursor 不是并发安全的，使用完毕后必须调用Close释放数据库连接。
+//
+// Eg:
+//
+// cursor, err := db.Model("user").Where("status", 1).Cursor()
+// defer cursor.Close()
+// for cursor.Next() { cursor.Scan(&user) }
+// err = cursor.Err()
+type Cursor struct {
+	db          DB                 // 底层数据库接口。
+	rows        *sql.Rows          // 底层查询结果集。
+	cancelFunc  context.CancelFunc // 查询超时上下文的取消函数。
+	columnNames []string           // 结果集的字段名称。
+	columnTypes []string           // 结果集的字段类型。
+	values      []interface{}      // 当前记录的原始字段值。
+	scanArgs    []interface{}      // 用于rows.Scan的字段值指针。
+	record      Record             // 当前记录。
+	err         error              // 迭代过程中产生的错误。
+	closed      bool               // 是否已关闭。
+}
+
+// Cursor 对Model执行“select from...”语句，并返回查询结果的流式迭代器，查询结果不会全部加载到内存中。
+//
+// 查询使用模型的上下文，配置的QueryTimeout作用于从查询开始到迭代结束的整个过程，上下文取消或超时后迭代终止，
+// 通过Err返回对应的错误。请注意: 流式查询不支持缓存，也不会执行AfterSelect钩子。
+func (m *Model) Cursor() (*Cursor, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	sqlWithHolder, holderArgs := m.getSelectSqlAndArgs(false)
+	sqlWithHolder, holderArgs, err := m.withCteSql(sqlWithHolder, holderArgs)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ctx        = m.db.GetCtx()
+		cancelFunc = context.CancelFunc(func() {})
+	)
+	if m.db.GetConfig().QueryTimeout > 0 {
+		ctx, cancelFunc = context.WithTimeout(ctx, m.db.GetConfig().QueryTimeout)
+	}
+	rows, err := m.db.doQueryContext(ctx, m.getLink(false), sqlWithHolder, m.mergeArguments(holderArgs)...)
+	if err != nil {
+		cancelFunc()
+		return nil, err
+	}
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		cancelFunc()
+		return nil, err
+	}
+	cursor := &Cursor{
+		db:          m.db,
+		rows:        rows,
+		cancelFunc:  cancelFunc,
+		columnNames: make([]string, len(columns)),
+		columnTypes: make([]string, len(columns)),
+		values:      make([]interface{}, len(columns)),
+		scanArgs:    make([]interface{}, len(columns)),
+	}
+	for k, v := range columns {
+		cursor.columnTypes[k] = v.DatabaseTypeName()
+		cursor.columnNames[k] = v.Name()
+		cursor.scanArgs[k] = &cursor.values[k]
+	}
+	return cursor, nil
+}
+
+// Iterate 对Model执行“select from...”语句，并逐条读取记录调用<handler>，查询结果不会全部加载到内存中。
+//
+// <handler>返回错误时停止迭代并返回该错误，其他同Cursor。
+func (m *Model) Iterate(handler func(record Record) error) error {
+	cursor, err := m.Cursor()
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+	for cursor.Next() {
+		if err = handler(cursor.Record()); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Next 读取下一条记录，如果没有更多记录或者发生错误则返回false，并自动关闭迭代器，错误通过Err获取。
+func (c *Cursor) Next() bool {
+	if c.closed {
+		return false
+	}
+	if !c.rows.Next() {
+		c.err = c.rows.Err()
+		c.Close()
+		return false
+	}
+	if err := c.rows.Scan(c.scanArgs...); err != nil {
+		c.err = err
+		c.Close()
+		return false
+	}
+	c.record = make(Record, len(c.values))
+	for i, value := range c.values {
+		if value == nil {
+			c.record[c.columnNames[i]] = gvar.New(nil)
+		} else {
+			c.record[c.columnNames[i]] = gvar.New(c.db.convertFieldValueToLocalValue(value, c.columnTypes[i]))
+		}
+	}
+	return true
+}
+
+// Record 返回当前记录，需要在Next返回true之后调用。
+func (c *Cursor) Record() Record {
+	return c.record
+}
+
+// Scan 将当前记录转换为参数<pointer>指定的结构体，参数<pointer>的类型应为*struct/**struct。
+func (c *Cursor) Scan(pointer interface{}) error {
+	if c.record == nil {
+		return sql.ErrNoRows
+	}
+	return c.record.Struct(pointer)
+}
+
+// Err 返回迭代过程中产生的错误，包括上下文取消或者查询超时的错误。
+func (c *Cursor) Err() error {
+	return c.err
+}
+
+// Close 关闭迭代器并释放数据库连接，可以重复调用。
+func (c *Cursor) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	err := c.rows.Close()
+	c.cancelFunc()
+	return err
+}
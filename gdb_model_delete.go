@@ -10,7 +10,6 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/gogf/gf/errors/gerror"
-	"github.com/gogf/gf/os/gtime"
 	"github.com/gogf/gf/text/gstr"
 )
 
@@ -23,28 +22,46 @@ func (m *Model) Delete(where ...interface{}) (result sql.Result, err error) {
 	if len(where) > 0 {
 		return m.Where(where[0], where[1:]...).Delete()
 	}
-	defer func() {
-		if err == nil {
-			m.checkAndRemoveCache()
-		}
-	}()
+	if m.err != nil {
+		return nil, m.err
+	}
 	var (
 		fieldNameDelete                               = m.getSoftFieldNameDeleted()
 		conditionWhere, conditionExtra, conditionArgs = m.formatCondition(false, false)
+		handlers                                      = m.getHookHandlers()
+		hookInput                                     = m.newHookInput()
 	)
+	hookInput.Condition = conditionWhere + conditionExtra
+	hookInput.Args = conditionArgs
+	if len(handlers) > 0 {
+		if err = m.callHooks(hookEventBeforeDelete, handlers, hookInput); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		if err != nil {
+			return
+		}
+		m.checkAndRemoveCache()
+		if len(handlers) > 0 {
+			hookInput.SqlResult = result
+			err = m.callHooks(hookEventAfterDelete, handlers, hookInput)
+		}
+	}()
 	// Soft deleting.
 	if !m.unscoped && fieldNameDelete != "" {
+		strategy := m.getSoftDeleteStrategy(m.getPrimaryTableName(), fieldNameDelete)
 		return m.db.DoUpdate(
 			m.getLink(true),
 			m.tables,
 			fmt.Sprintf(`%s=?`, m.db.QuoteString(fieldNameDelete)),
-			conditionWhere+conditionExtra,
-			append([]interface{}{gtime.Now().String()}, conditionArgs...),
+			hookInput.Condition,
+			m.mergeTablesArguments(append([]interface{}{strategy.DeletedValue(m.getSoftTime())}, hookInput.Args...))...,
 		)
 	}
-	conditionStr := conditionWhere + conditionExtra
+	conditionStr := hookInput.Condition
 	if !gstr.ContainsI(conditionStr, " WHERE ") {
 		return nil, gerror.New("there should be WHERE condition statement for DELETE operation")
 	}
-	return m.db.DoDelete(m.getLink(true), m.tables, conditionStr, conditionArgs...)
+	return m.db.DoDelete(m.getLink(true), m.tables, conditionStr, m.mergeTablesArguments(hookInput.Args)...)
 }
@@ -26,17 +26,25 @@ func (m *Model) Filter() *Model {
 
 // Fields 指定需要操作的表字段，包括查询字段、写入字段、更新字段等，多个字段使用字符'，'连接。
 //
-// 参数<fieldNamesOrMapStruct>的类型可以是string/map/*map/struct/*struct。
+// 参数<fieldNamesOrMapStruct>的类型可以是string/map/*map/struct/*struct，也可以是作为子查询的*Model对象，如:
+//
+// Fields("id", db.Model("user_detail").Fields("COUNT(1)").Where("uid=user.id").As("cnt"))
 func (m *Model) Fields(fieldNamesOrMapStruct ...interface{}) *Model {
 	length := len(fieldNamesOrMapStruct)
 	if length == 0 {
 		return m
 	}
+	for _, v := range fieldNamesOrMapStruct {
+		if _, ok := v.(*Model); ok {
+			return m.doFieldsWithSubQuery(fieldNamesOrMapStruct)
+		}
+	}
 	switch {
 	// String slice.
 	case length >= 2:
 		model := m.getModel()
 		model.fields = gstr.Join(m.mappingAndFilterToTableFields(gconv.Strings(fieldNamesOrMapStruct)), ",")
+		model.fieldsArgs = nil
 		return model
 	// It need type asserting.
 	case length == 1:
@@ -49,11 +57,42 @@ func (m *Model) Fields(fieldNamesOrMapStruct ...interface{}) *Model {
 		default:
 			model.fields = gstr.Join(m.mappingAndFilterToTableFields(gutil.Keys(r)), ",")
 		}
+		model.fieldsArgs = nil
 		return model
 	}
 	return m
 }
 
+// doFieldsWithSubQuery 设置包含子查询的操作字段，子查询的参数按照其在字段中出现的顺序保存。
+func (m *Model) doFieldsWithSubQuery(fields []interface{}) *Model {
+	var (
+		model       = m.getModel()
+		fieldsArray = make([]string, 0, len(fields))
+		fieldsArgs  = make([]interface{}, 0)
+	)
+	for _, v := range fields {
+		if subModel, ok := v.(*Model); ok {
+			subQuery, subArgs := subModel.getSubQuery()
+			fieldsArray = append(fieldsArray, subQuery)
+			fieldsArgs = append(fieldsArgs, subArgs...)
+			continue
+		}
+		fieldsArray = append(fieldsArray, m.mappingAndFilterToTableFields([]string{gconv.String(v)})...)
+	}
+	model.fields = gstr.Join(fieldsArray, ",")
+	model.fieldsArgs = fieldsArgs
+	return model
+}
+
+// Distinct 设置查询语句为“SELECT DISTINCT ...”，去除查询结果中的重复记录。
+//
+// Count会自动统计去重后的记录数，聚合统计方法如Sum/CountColumn等会对去重后的字段值进行统计。
+func (m *Model) Distinct() *Model {
+	model := m.getModel()
+	model.distinct = true
+	return model
+}
+
 // FieldsEx 指定不被操作的表字段, 多个字段使用字符'，'连接。(指定例外的字段，可用于查询字段、写入字段、更新字段等过滤)
 //
 // 请注意: 此函数仅支持单表操作。参数<fieldNamesOrMapStruct>的类型可以是string/map/*map/struct/*struct。
@@ -0,0 +1,209 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/gogf/gf/text/gstr"
+)
+
+// HookInput 是模型生命周期钩子函数的输入参数。
+type HookInput struct {
+	Ctx       context.Context // 当前操作的上下文。
+	Model     *Model          // 当前操作的模型对象。
+	Table     string          // 当前操作的表名(不包含安全字符)。
+	Data      List            // 插入/更新的数据，Before钩子中可直接修改。更新操作使用字符串数据时为nil。
+	Condition string          // 更新/删除/查询的条件语句，包含"WHERE"关键字，Before钩子中可修改。
+	Args      []interface{}   // 条件语句的参数，Before钩子中可修改。
+	Result    Result          // 查询结果，AfterSelect钩子中可修改。
+	SqlResult sql.Result      // 插入/更新/删除的执行结果，仅在After钩子中有效。
+}
+
+// HookFunc 是模型生命周期钩子函数，返回错误时将中止当前操作并将该错误返回给调用方。
+type HookFunc func(in *HookInput) error
+
+// HookHandler 管理一组模型生命周期钩子函数，未设置的钩子函数将被忽略。
+type HookHandler struct {
+	BeforeInsert HookFunc // 在插入数据之前调用。
+	AfterInsert  HookFunc // 在插入数据成功之后调用。
+	BeforeUpdate HookFunc // 在更新数据之前调用。
+	AfterUpdate  HookFunc // 在更新数据成功之后调用。
+	BeforeDelete HookFunc // 在删除数据(包括软删除)之前调用。
+	AfterDelete  HookFunc // 在删除数据(包括软删除)成功之后调用。
+	AfterSelect  HookFunc // 在查询数据成功之后调用。
+}
+
+// BeforeInserter 由需要在插入之前执行逻辑的实体结构体实现，通过Data传入时将被自动调用。
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInserter 由需要在插入成功之后执行逻辑的实体结构体实现，通过Data传入时将被自动调用。
+type AfterInserter interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// BeforeUpdater 由需要在更新之前执行逻辑的实体结构体实现，通过Data传入时将被自动调用。
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdater 由需要在更新成功之后执行逻辑的实体结构体实现，通过Data传入时将被自动调用。
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+const (
+	hookEventBeforeInsert = iota
+	hookEventAfterInsert
+	hookEventBeforeUpdate
+	hookEventAfterUpdate
+	hookEventBeforeDelete
+	hookEventAfterDelete
+	hookEventAfterSelect
+)
+
+var (
+	// hookMutex 用于并发安全地读写hookMap。
+	hookMutex sync.RWMutex
+
+	// hookMap 管理所有注册的钩子，键名为表名，空键名表示全局钩子。
+	hookMap = make(map[string][]HookHandler)
+)
+
+// RegisterHook 为指定的表注册模型生命周期钩子，<table>可以是带前缀或者不带前缀的表名。
+//
+// 同一个表可以注册多次，钩子按注册顺序执行，且在全局钩子之后执行。
+func RegisterHook(table string, handler HookHandler) {
+	hookMutex.Lock()
+	defer hookMutex.Unlock()
+	hookMap[table] = append(hookMap[table], handler)
+}
+
+// RegisterGlobalHook 注册对所有表生效的模型生命周期钩子。
+func RegisterGlobalHook(handler HookHandler) {
+	RegisterHook("", handler)
+}
+
+// UnregisterHook 移除指定的表通过RegisterHook注册的所有模型生命周期钩子，<table>需与注册时使用的表名一致。
+func UnregisterHook(table string) {
+	hookMutex.Lock()
+	defer hookMutex.Unlock()
+	delete(hookMap, table)
+}
+
+// UnregisterGlobalHook 移除通过RegisterGlobalHook注册的所有全局钩子。
+func UnregisterGlobalHook() {
+	UnregisterHook("")
+}
+
+// getHookHandlers 返回当前模型需要执行的所有钩子，全局钩子在前，表钩子在后。
+func (m *Model) getHookHandlers() []HookHandler {
+	hookMutex.RLock()
+	defer hookMutex.RUnlock()
+	if len(hookMap) == 0 {
+		return nil
+	}
+	var (
+		table    = m.getHookTableName()
+		handlers = append([]HookHandler(nil), hookMap[""]...)
+	)
+	handlers = append(handlers, hookMap[table]...)
+	if prefix := m.db.GetPrefix(); prefix != "" && gstr.HasPrefix(table, prefix) {
+		handlers = append(handlers, hookMap[table[len(prefix):]]...)
+	}
+	return handlers
+}
+
+// getHookTableName 返回当前模型主表的表名，不包含安全字符。
+func (m *Model) getHookTableName() string {
+	charL, charR := m.db.GetChars()
+	return gstr.Trim(m.getPrimaryTableName(), charL+charR)
+}
+
+// newHookInput 创建并返回当前模型的钩子输入参数。
+func (m *Model) newHookInput() *HookInput {
+	return &HookInput{
+		Ctx:   m.db.GetCtx(),
+		Model: m,
+		Table: m.getHookTableName(),
+	}
+}
+
+// callHooks 按顺序执行<handlers>中指定事件的钩子函数，任一钩子函数返回错误时立即返回该错误。
+func (m *Model) callHooks(event int, handlers []HookHandler, in *HookInput) error {
+	for _, handler := range handlers {
+		var f HookFunc
+		switch event {
+		case hookEventBeforeInsert:
+			f = handler.BeforeInsert
+		case hookEventAfterInsert:
+			f = handler.AfterInsert
+		case hookEventBeforeUpdate:
+			f = handler.BeforeUpdate
+		case hookEventAfterUpdate:
+			f = handler.AfterUpdate
+		case hookEventBeforeDelete:
+			f = handler.BeforeDelete
+		case hookEventAfterDelete:
+			f = handler.AfterDelete
+		case hookEventAfterSelect:
+			f = handler.AfterSelect
+		}
+		if f == nil {
+			continue
+		}
+		if err := f(in); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callEntityHooks 执行通过Data传入的实体结构体实现的钩子方法，返回是否有钩子方法被调用。
+func (m *Model) callEntityHooks(event int) (called bool, err error) {
+	ctx := m.db.GetCtx()
+	for _, entity := range m.entities {
+		switch event {
+		case hookEventBeforeInsert:
+			if v, ok := entity.(BeforeInserter); ok {
+				called, err = true, v.BeforeInsert(ctx)
+			}
+		case hookEventAfterInsert:
+			if v, ok := entity.(AfterInserter); ok {
+				called, err = true, v.AfterInsert(ctx)
+			}
+		case hookEventBeforeUpdate:
+			if v, ok := entity.(BeforeUpdater); ok {
+				called, err = true, v.BeforeUpdate(ctx)
+			}
+		case hookEventAfterUpdate:
+			if v, ok := entity.(AfterUpdater); ok {
+				called, err = true, v.AfterUpdate(ctx)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// getEntitiesData 将通过Data传入的实体结构体重新转换为操作数据，用于获取Before钩子方法对实体的修改。
+func (m *Model) getEntitiesData() interface{} {
+	if _, ok := m.data.(List); ok {
+		list := make(List, len(m.entities))
+		for i, entity := range m.entities {
+			list[i] = ConvertDataForTableRecord(entity)
+		}
+		return list
+	}
+	return ConvertDataForTableRecord(m.entities[0])
+}
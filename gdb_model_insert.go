@@ -9,7 +9,6 @@ package gdb
 import (
 	"database/sql"
 	"github.com/gogf/gf/errors/gerror"
-	"github.com/gogf/gf/os/gtime"
 	"github.com/gogf/gf/text/gstr"
 	"github.com/gogf/gf/util/gconv"
 	"github.com/gogf/gf/util/gutil"
@@ -23,6 +22,80 @@ func (m *Model) Batch(batch int) *Model {
 	return model
 }
 
+// OnConflict 指定Save/Replace/InsertIgnore操作的冲突检测字段，多个字段使用字符','连接或者分别传入，
+// 用于pgsql/sqlite的“ON CONFLICT (columns)”子句。未指定时根据表的主键/唯一索引自动检测。
+//
+// 请注意: mysql的冲突检测由表的全部主键/唯一索引决定，因此该设置对mysql无效。
+func (m *Model) OnConflict(columns ...string) *Model {
+	model := m.getModel()
+	model.onConflict = make([]string, 0, len(columns))
+	for _, v := range columns {
+		model.onConflict = append(model.onConflict, gstr.SplitAndTrim(v, ",")...)
+	}
+	return model
+}
+
+// OnDuplicate 设置Save操作数据冲突时更新的字段，未设置时更新全部写入的字段（创建时间字段除外）。
+//
+// 参数<onDuplicate>可以是使用字符','连接的字段名称或者[]string类型的字段列表，冲突时这些字段更新为写入的值；
+// 也可以是map类型，键为更新的字段，值为写入值的字段名称、Raw类型的sql表达式或者Counter类型的计数值。
+//
+// Eg:
+//
+// OnDuplicate("nickname,password")
+//
+// OnDuplicate(g.Map{"nickname": "passport", "count": gdb.Counter{Field: "count", Value: 1}})
+//
+// OnDuplicate(g.Map{"count": gdb.Raw("count+VALUES(count)")})
+func (m *Model) OnDuplicate(onDuplicate ...interface{}) *Model {
+	model := m.getModel()
+	switch len(onDuplicate) {
+	case 0:
+		model.onDuplicate = nil
+	case 1:
+		model.onDuplicate = onDuplicate[0]
+	default:
+		model.onDuplicate = onDuplicate
+	}
+	return model
+}
+
+// OnDuplicateEx 设置Save操作数据冲突时不更新的字段，参数<onDuplicateEx>可以是使用字符','连接的字段名称
+// 或者[]string类型的字段列表。
+//
+// Eg:
+//
+// OnDuplicateEx("passport,created_at")
+func (m *Model) OnDuplicateEx(onDuplicateEx ...interface{}) *Model {
+	model := m.getModel()
+	switch len(onDuplicateEx) {
+	case 0:
+		model.onDuplicateEx = nil
+	case 1:
+		model.onDuplicateEx = onDuplicateEx[0]
+	default:
+		model.onDuplicateEx = onDuplicateEx
+	}
+	return model
+}
+
+// Returning 指定写入操作完成后返回的字段，多个字段使用字符','连接或者分别传入，"*"表示返回全部字段，
+// 返回的记录通过写入结果的SqlResult.Returning方法获取。
+//
+// 该功能使用pgsql/sqlite(3.35.0+)的RETURNING、mssql的OUTPUT INSERTED以及oracle的RETURNING INTO子句实现，
+// 其中oracle不支持"*"，且仅支持Insert操作。mysql不支持返回写入的记录，设置后写入操作将返回错误。
+//
+// Eg:
+//
+// r, err := db.Model("user").Returning("id,created_at").Data(data).Insert()
+//
+// records := r.(*gdb.SqlResult).Returning()
+func (m *Model) Returning(columns ...string) *Model {
+	model := m.getModel()
+	model.returning = m.splitColumns(columns)
+	return model
+}
+
 // Data 设置model的操作数据，参数<data>可以是string/map/gmap/slice/struct/*struct等类型。
 //
 // 请注意: 如果“data”是map/slice类型，则它对“data”使用浅值复制，以避免在函数内更改它。
@@ -36,6 +109,7 @@ func (m *Model) Batch(batch int) *Model {
 // Data(g.Slice{g.Map{"uid": 10000, "name":"john"}, g.Map{"uid": 20000, "name":"smith"})
 func (m *Model) Data(data ...interface{}) *Model {
 	model := m.getModel()
+	model.entities = nil
 	if len(data) > 1 {
 		if s := gconv.String(data[0]); gstr.Contains(s, "?") {
 			model.data = s
@@ -72,11 +146,16 @@ func (m *Model) Data(data ...interface{}) *Model {
 			}
 			switch kind {
 			case reflect.Slice, reflect.Array:
-				list := make(List, rv.Len())
+				var (
+					list     = make(List, rv.Len())
+					entities = make([]interface{}, rv.Len())
+				)
 				for i := 0; i < rv.Len(); i++ {
-					list[i] = ConvertDataForTableRecord(rv.Index(i).Interface())
+					entities[i] = rv.Index(i).Interface()
+					list[i] = ConvertDataForTableRecord(entities[i])
 				}
 				model.data = list
+				model.entities = entities
 			case reflect.Map:
 				model.data = ConvertDataForTableRecord(data[0])
 			case reflect.Struct:
@@ -89,8 +168,10 @@ func (m *Model) Data(data ...interface{}) *Model {
 						list[i] = ConvertDataForTableRecord(array[i])
 					}
 					model.data = list
+					model.entities = array
 				} else {
 					model.data = ConvertDataForTableRecord(data[0])
+					model.entities = []interface{}{data[0]}
 				}
 			default:
 				model.data = data[0]
@@ -134,26 +215,74 @@ func (m *Model) Save(data ...interface{}) (result sql.Result, err error) {
 
 // doInsertWithOption 插入带有选项参数的数据。
 func (m *Model) doInsertWithOption(option int) (result sql.Result, err error) {
-	defer func() {
-		if err == nil {
-			m.checkAndRemoveCache()
-		}
-	}()
 	if m.data == nil {
 		return nil, gerror.New("inserting into table with empty data")
 	}
 	var (
-		nowString       = gtime.Now().String()
+		data            = m.data
+		now             = m.getSoftTime()
 		fieldNameCreate = m.getSoftFieldNameCreated()
 		fieldNameUpdate = m.getSoftFieldNameUpdated()
 		fieldNameDelete = m.getSoftFieldNameDeleted()
+		createValue     interface{}
+		updateValue     interface{}
+		liveValue       interface{}
 	)
-	// Batch operation.
-	if list, ok := m.data.(List); ok {
-		batch := defaultBatchNumber
-		if m.batch > 0 {
-			batch = m.batch
+	// 根据字段类型转换自动写入的创建/更新时间，如: 时间戳、日期、日期时间。
+	if fieldNameCreate != "" {
+		createValue = m.getSoftTimeValue(m.getPrimaryTableName(), fieldNameCreate, now)
+	}
+	if fieldNameUpdate != "" {
+		updateValue = m.getSoftTimeValue(m.getPrimaryTableName(), fieldNameUpdate, now)
+	}
+	// 软删除字段为数值类型时需要写入未删除记录的值，如: 0，保存操作数据冲突时不更新该字段，以免恢复已被软删除的记录。
+	if !m.unscoped && fieldNameDelete != "" {
+		liveValue = m.getSoftDeleteStrategy(m.getPrimaryTableName(), fieldNameDelete).LiveValue()
+	}
+	// 实体的钩子方法可能修改了实体属性，因此需要重新转换操作数据。
+	if called, err := m.callEntityHooks(hookEventBeforeInsert); err != nil {
+		return nil, err
+	} else if called {
+		data = m.getEntitiesData()
+	}
+	handlers := m.getHookHandlers()
+	hookInput := m.newHookInput()
+	if len(handlers) > 0 {
+		switch v := data.(type) {
+		case List:
+			hookInput.Data = v
+		case Map:
+			hookInput.Data = List{v}
+		}
+		if hookInput.Data != nil {
+			if err = m.callHooks(hookEventBeforeInsert, handlers, hookInput); err != nil {
+				return nil, err
+			}
+			if len(hookInput.Data) == 0 {
+				return nil, gerror.New("inserting into table with empty data")
+			}
+			if _, ok := data.(Map); ok && len(hookInput.Data) == 1 {
+				data = hookInput.Data[0]
+			} else {
+				data = hookInput.Data
+			}
+		}
+	}
+	defer func() {
+		if err != nil {
+			return
+		}
+		m.checkAndRemoveCache()
+		if _, err = m.callEntityHooks(hookEventAfterInsert); err != nil {
+			return
+		}
+		if len(handlers) > 0 {
+			hookInput.SqlResult = result
+			err = m.callHooks(hookEventAfterInsert, handlers, hookInput)
 		}
+	}()
+	// Batch operation.
+	if list, ok := data.(List); ok {
 		newData, err := m.filterDataForInsertOrUpdate(list)
 		if err != nil {
 			return nil, err
@@ -164,24 +293,33 @@ func (m *Model) doInsertWithOption(option int) (result sql.Result, err error) {
 			for k, v := range list {
 				gutil.MapDelete(v, fieldNameCreate, fieldNameUpdate, fieldNameDelete)
 				if fieldNameCreate != "" {
-					v[fieldNameCreate] = nowString
+					v[fieldNameCreate] = createValue
 				}
 				if fieldNameUpdate != "" {
-					v[fieldNameUpdate] = nowString
+					v[fieldNameUpdate] = updateValue
 				}
 				list[k] = v
 			}
 		}
-		return m.db.DoBatchInsert(
+		insertOption := m.getDoInsertOption(option)
+		if liveValue != nil {
+			for _, v := range list {
+				if _, ok := v[fieldNameDelete]; !ok {
+					v[fieldNameDelete] = liveValue
+					m.excludeSoftDeletedFieldOnSave(&insertOption, fieldNameDelete)
+				}
+			}
+		}
+		return m.getInsertDB(insertOption).DoBatchInsert(
 			m.getLink(true),
 			m.tables,
 			newData,
 			option,
-			batch,
+			insertOption.BatchCount,
 		)
 	}
 	// 单次操作。
-	if data, ok := m.data.(Map); ok {
+	if data, ok := data.(Map); ok {
 		newData, err := m.filterDataForInsertOrUpdate(data)
 		if err != nil {
 			return nil, err
@@ -191,13 +329,20 @@ func (m *Model) doInsertWithOption(option int) (result sql.Result, err error) {
 		if !m.unscoped && (fieldNameCreate != "" || fieldNameUpdate != "") {
 			gutil.MapDelete(data, fieldNameCreate, fieldNameUpdate, fieldNameDelete)
 			if fieldNameCreate != "" {
-				data[fieldNameCreate] = nowString
+				data[fieldNameCreate] = createValue
 			}
 			if fieldNameUpdate != "" {
-				data[fieldNameUpdate] = nowString
+				data[fieldNameUpdate] = updateValue
 			}
 		}
-		return m.db.DoInsert(
+		insertOption := m.getDoInsertOption(option)
+		if liveValue != nil {
+			if _, ok := data[fieldNameDelete]; !ok {
+				data[fieldNameDelete] = liveValue
+				m.excludeSoftDeletedFieldOnSave(&insertOption, fieldNameDelete)
+			}
+		}
+		return m.getInsertDB(insertOption).DoInsert(
 			m.getLink(true),
 			m.tables,
 			newData,
@@ -206,3 +351,80 @@ func (m *Model) doInsertWithOption(option int) (result sql.Result, err error) {
 	}
 	return nil, gerror.New("inserting into table with invalid data type")
 }
+
+// getInsertDB 返回上下文中携带写入选项<option>的数据库对象，用于调用DoInsert/DoBatchInsert，
+// 写入选项中的冲突处理、返回字段等扩展选项由DoInsert/DoBatchInsert通过DoInsertOptionFromCtx获取。
+//
+// 写入类型及批量数量已经通过DoInsert/DoBatchInsert的参数传递，没有扩展选项时直接返回当前的数据库对象。
+func (m *Model) getInsertDB(option DoInsertOption) DB {
+	if len(option.OnConflict) == 0 && len(option.OnDuplicate) == 0 && len(option.OnDuplicateMap) == 0 &&
+		len(option.OnDuplicateEx) == 0 && len(option.Returning) == 0 && option.PrimaryKey == "" {
+		return m.db
+	}
+	return m.db.Ctx(WithDoInsertOption(m.db.GetCtx(), option))
+}
+
+// getDoInsertOption 返回写入操作类型为<insertOption>时传递给DoInsert/DoBatchInsert的写入选项。
+func (m *Model) getDoInsertOption(insertOption int) DoInsertOption {
+	option := DoInsertOption{
+		InsertOption: insertOption,
+		BatchCount:   m.batch,
+		OnConflict:   m.onConflict,
+	}
+	if option.BatchCount <= 0 {
+		option.BatchCount = defaultBatchNumber
+	}
+	// 驱动不支持LastInsertId时，通过返回子句获取写入记录的主键值，
+	// 返回子句有使用限制的驱动(如mssql)只在调用Returning时返回主键值。
+	if len(m.returning) > 0 || (!m.db.IsLastInsertIdSupported() && !m.isReturningOnDemand()) {
+		option.Returning = m.returning
+		option.PrimaryKey = m.getPrimaryKey()
+	}
+	if m.onDuplicate != nil {
+		switch v := m.onDuplicate.(type) {
+		case Map:
+			option.OnDuplicateMap = gutil.MapCopy(v)
+		case string, []string, []interface{}:
+			option.OnDuplicate = m.splitColumns(v)
+		default:
+			kind := reflect.Indirect(reflect.ValueOf(v)).Kind()
+			if kind == reflect.Slice || kind == reflect.Array {
+				option.OnDuplicate = m.splitColumns(v)
+			} else {
+				option.OnDuplicateMap = gconv.Map(v)
+			}
+		}
+	}
+	if m.onDuplicateEx != nil {
+		option.OnDuplicateEx = m.splitColumns(m.onDuplicateEx)
+	}
+	return option
+}
+
+// excludeSoftDeletedFieldOnSave 将自动写入的软删除字段<field>加入Save操作数据冲突时不更新的字段，
+// 只在未通过OnDuplicate指定更新字段时有效，以免恢复已被软删除的记录。
+func (m *Model) excludeSoftDeletedFieldOnSave(option *DoInsertOption, field string) {
+	if option.InsertOption != insertOptionSave || len(option.OnDuplicate) > 0 || len(option.OnDuplicateMap) > 0 {
+		return
+	}
+	if !gstr.InArray(option.OnDuplicateEx, field) {
+		option.OnDuplicateEx = append(option.OnDuplicateEx, field)
+	}
+}
+
+// isReturningOnDemand 检查驱动是否只在调用Returning时才使用返回子句。
+func (m *Model) isReturningOnDemand() bool {
+	if v, ok := m.db.(interface{ isReturningOnDemand() bool }); ok {
+		return v.isReturningOnDemand()
+	}
+	return false
+}
+
+// splitColumns 将string/[]string等类型的参数<columns>转换为字段名称数组，每一项可以是使用字符','连接的多个字段。
+func (m *Model) splitColumns(columns interface{}) []string {
+	array := make([]string, 0)
+	for _, v := range gconv.Strings(columns) {
+		array = append(array, gstr.SplitAndTrim(v, ",")...)
+	}
+	return array
+}
@@ -9,6 +9,7 @@ package gdb
 import (
 	"fmt"
 	"github.com/gogf/gf/text/gstr"
+	"github.com/gogf/gf/util/gconv"
 )
 
 // isSubQuery 检查并返回给定字符串是否为子查询sql字符串。
@@ -31,8 +32,10 @@ func isSubQuery(s string) bool {
 // Table("user", "u").LeftJoin("user_detail", "ud", "ud.uid=u.uid");
 //
 // Table("user", "u").LeftJoin("SELECT xxx FROM xxx AS a", "a.uid=u.uid")
-func (m *Model) LeftJoin(table ...string) *Model {
-	return m.doJoin("LEFT", table...)
+//
+// Table("user", "u").LeftJoin(db.Model("user_detail").Where("status", 1), "a", "a.uid=u.uid")
+func (m *Model) LeftJoin(tableOrSubQueryAndJoinConditions ...interface{}) *Model {
+	return m.doJoin("LEFT", tableOrSubQueryAndJoinConditions...)
 }
 
 // RightJoin 对Model执行“right join ... on ...”语句。
@@ -44,8 +47,10 @@ func (m *Model) LeftJoin(table ...string) *Model {
 // Table("user", "u").RightJoin("user_detail", "ud", "ud.uid=u.uid");
 //
 // Table("user", "u").RightJoin("SELECT xxx FROM xxx AS a", "a.uid=u.uid")
-func (m *Model) RightJoin(table ...string) *Model {
-	return m.doJoin("RIGHT", table...)
+//
+// Table("user", "u").RightJoin(db.Model("user_detail").Where("status", 1), "a", "a.uid=u.uid")
+func (m *Model) RightJoin(tableOrSubQueryAndJoinConditions ...interface{}) *Model {
+	return m.doJoin("RIGHT", tableOrSubQueryAndJoinConditions...)
 }
 
 // InnerJoin 对模型执行“inner join ... on ...”语句。
@@ -57,8 +62,10 @@ func (m *Model) RightJoin(table ...string) *Model {
 // Table("user", "u").InnerJoin("user_detail", "ud", "ud.uid=u.uid");
 //
 // Table("user", "u").InnerJoin("SELECT xxx FROM xxx AS a", "a.uid=u.uid")
-func (m *Model) InnerJoin(table ...string) *Model {
-	return m.doJoin("INNER", table...)
+//
+// Table("user", "u").InnerJoin(db.Model("user_detail").Where("status", 1), "a", "a.uid=u.uid")
+func (m *Model) InnerJoin(tableOrSubQueryAndJoinConditions ...interface{}) *Model {
+	return m.doJoin("INNER", tableOrSubQueryAndJoinConditions...)
 }
 
 // doJoin 对模型执行 "left/right/inner join ... on ..." 语句。
@@ -72,19 +79,33 @@ func (m *Model) InnerJoin(table ...string) *Model {
 // Table("user", "u").InnerJoin("SELECT xxx FROM xxx AS a", "a.uid=u.uid")//
 //
 // 相关问题: https://github.com/gogf/gf/issues/1024
-func (m *Model) doJoin(operator string, table ...string) *Model {
+func (m *Model) doJoin(operator string, tableOrSubQueryAndJoinConditions ...interface{}) *Model {
 	var (
 		model   = m.getModel()
 		joinStr = ""
+		table   = make([]string, len(tableOrSubQueryAndJoinConditions))
 	)
+	for i, v := range tableOrSubQueryAndJoinConditions {
+		if _, ok := v.(*Model); !ok {
+			table[i] = gconv.String(v)
+		}
+	}
 	if len(table) > 0 {
-		if isSubQuery(table[0]) {
+		if subModel, ok := tableOrSubQueryAndJoinConditions[0].(*Model); ok {
+			var subArgs []interface{}
+			joinStr, subArgs = subModel.getSubQuery()
+			model.tablesArgs = append(model.tablesArgs, subArgs...)
+		} else if isSubQuery(table[0]) {
 			joinStr = gstr.Trim(table[0])
 			if joinStr[0] != '(' {
 				joinStr = "(" + joinStr + ")"
 			}
 		} else {
-			joinStr = m.db.QuotePrefixTableName(table[0])
+			joinStr = m.quoteTableName(table[0])
+			offset := len(model.tables) + len(fmt.Sprintf(" %s JOIN ", operator))
+			if ref, ok := newTableRef(m.db, table[0], joinStr, offset); ok {
+				model.tableRefs = append(model.tableRefs, ref)
+			}
 		}
 	}
 	if len(table) > 2 {
@@ -0,0 +1,103 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"github.com/gogf/gf/errors/gerror"
+	"github.com/gogf/gf/text/gstr"
+)
+
+// After 设置键集（游标）分页的条件，查询字段<columns>的值在<lastValues>之后的记录，并按照<columns>升序排序，
+// 结合Limit使用即可实现不依赖OFFSET的分页，如: WHERE `id`>? ORDER BY `id` LIMIT 100。
+//
+// 参数<columns>支持使用字符','连接的多个字段组成的复合键，<lastValues>按顺序对应各字段上一页最后一条记录的值，
+// 为空时表示查询第一页。<lastValues>与<columns>的数量不一致时，执行查询操作返回错误。
+// 请注意: 该方法会覆盖通过Order设置的排序。
+//
+// Eg:
+//
+// db.Model("user").After("created_at,id", lastCreatedAt, lastId).Limit(100).All()
+func (m *Model) After(columns string, lastValues ...interface{}) *Model {
+	var (
+		model       = m.getModel()
+		columnArray = gstr.SplitAndTrim(columns, ",")
+	)
+	model.orderBy = m.db.QuoteString(gstr.Join(columnArray, ","))
+	if len(lastValues) == 0 {
+		return model
+	}
+	if len(lastValues) != len(columnArray) {
+		model.err = gerror.Newf(`the count of values %d does not match the count of columns "%s"`, len(lastValues), columns)
+		return model
+	}
+	// 复合键展开为: a>? OR (a=? AND b>?) OR (a=? AND b=? AND c>?)
+	var (
+		orArray = make([]string, 0, len(columnArray))
+		args    = make([]interface{}, 0)
+	)
+	for i := range columnArray {
+		andArray := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			andArray = append(andArray, m.db.QuoteString(columnArray[j])+"=?")
+			args = append(args, lastValues[j])
+		}
+		andArray = append(andArray, m.db.QuoteString(columnArray[i])+">?")
+		args = append(args, lastValues[i])
+		if len(andArray) > 1 {
+			orArray = append(orArray, "("+gstr.Join(andArray, " AND ")+")")
+		} else {
+			orArray = append(orArray, andArray[0])
+		}
+	}
+	if len(orArray) == 1 {
+		return model.Where(orArray[0], args...)
+	}
+	// 整个条件使用括号包裹，以便与其他条件正确组合。
+	return model.Where("("+gstr.Join(orArray, " OR ")+")", args...)
+}
+
+// ChunkById 使用键集分页按照字段<column>升序迭代查询结果，每次查询<size>条记录并调用<callback>，
+// 与Chunk不同，它不使用OFFSET，在大数据量或者迭代过程中数据发生变化时也不会跳过或重复记录。
+//
+// 参数<column>应当是唯一的字段（如主键），也可以是使用字符','连接的多个字段组成的复合键，
+// 查询结果中需要包含这些字段。<callback>返回错误时停止迭代并返回该错误。
+func (m *Model) ChunkById(column string, size int, callback func(result Result) error) error {
+	if size <= 0 {
+		return gerror.Newf(`invalid chunk size: %d`, size)
+	}
+	var (
+		charL, charR = m.db.GetChars()
+		columnArray  = gstr.SplitAndTrim(column, ",")
+		lastValues   []interface{}
+	)
+	for {
+		data, err := m.Clone().After(column, lastValues...).Limit(size).All()
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return nil
+		}
+		if err = callback(data); err != nil {
+			return err
+		}
+		if len(data) < size {
+			return nil
+		}
+		lastRecord := data[len(data)-1]
+		lastValues = make([]interface{}, len(columnArray))
+		for i, v := range columnArray {
+			// 去除字段的表名前缀及安全字符，如: `u`.`id`
+			key := gstr.Trim(v[gstr.PosR(v, ".")+1:], charL+charR)
+			value, ok := lastRecord[key]
+			if !ok {
+				return gerror.Newf(`column "%s" does not exist in the chunk result`, key)
+			}
+			lastValues[i] = value.Val()
+		}
+	}
+}
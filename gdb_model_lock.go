@@ -6,6 +6,14 @@
 
 package gdb
 
+import (
+	"reflect"
+
+	"github.com/gogf/gf/internal/structs"
+	"github.com/gogf/gf/text/gstr"
+	"github.com/gogf/gf/util/gutil"
+)
+
 // LockUpdate 用于创建for update锁，避免选择行被其它共享锁修改或删除，for update会阻塞其他锁定性读对锁定行的读取。
 //
 // 例如:
@@ -29,3 +37,72 @@ func (m *Model) LockShared() *Model {
 	model.lockInfo = "LOCK IN SHARE MODE"
 	return model
 }
+
+// getVersionField 检查并返回乐观锁的版本号字段名，不使用乐观锁时返回空字符串。
+//
+// 通过Data传入的实体结构体中使用“orm”标记为版本号的属性优先，如: `orm:"version"`、`orm:"revision,version"`，
+// 其次使用配置的VersionField，表中不存在该字段时返回空字符串。
+func (m *Model) getVersionField() string {
+	if len(m.entities) == 1 {
+		tagFields, _ := structs.TagFields(m.entities[0], []string{OrmTagForStruct})
+		for _, field := range tagFields {
+			array := gstr.SplitAndTrim(field.TagValue, ",")
+			if len(array) > 1 && array[1] == OrmTagForVersion {
+				return array[0]
+			}
+			if len(array) == 1 && array[0] == OrmTagForVersion {
+				return OrmTagForVersion
+			}
+		}
+	}
+	if config := m.db.GetConfig(); config.VersionField != "" {
+		return m.getSoftFieldName(m.getPrimaryTableName(), []string{config.VersionField})
+	}
+	return ""
+}
+
+// getVersionValue 从更新数据中检索并返回版本号字段<field>的值，作为乐观锁的更新条件。
+//
+// 如果更新数据中不存在该字段，或者该字段的值为Counter类型（调用者自行维护版本号），则返回nil。
+func (m *Model) getVersionValue(field string) interface{} {
+	data, ok := m.data.(Map)
+	if !ok {
+		return nil
+	}
+	_, value := gutil.MapPossibleItemByKey(data, field)
+	switch value.(type) {
+	case Counter, *Counter:
+		return nil
+	}
+	return value
+}
+
+// formatVersionData 将更新数据<data>中的版本号字段<field>替换为递增的计数，即: `field`=`field`+1。
+//
+// 如果<data>不是map/struct类型，或者该字段的值为Counter类型，则不做修改。
+func (m *Model) formatVersionData(data interface{}, field string) interface{} {
+	var (
+		rv   = reflect.ValueOf(data)
+		kind = rv.Kind()
+	)
+	if kind == reflect.Ptr {
+		rv = rv.Elem()
+		kind = rv.Kind()
+	}
+	if kind != reflect.Map && kind != reflect.Struct {
+		return data
+	}
+	dataMap := ConvertDataForTableRecord(data)
+	if key, value := gutil.MapPossibleItemByKey(dataMap, field); key != "" {
+		switch value.(type) {
+		case Counter, *Counter:
+			return dataMap
+		}
+		delete(dataMap, key)
+	}
+	dataMap[field] = &Counter{
+		Field: field,
+		Value: 1,
+	}
+	return dataMap
+}
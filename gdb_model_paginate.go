@@ -0,0 +1,62 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"sync"
+)
+
+// Paginate 查询第<page>页（从1开始）的<size>条记录并转换到<pointer>，同时返回不分页时的记录总数<total>。
+//
+// 可选参数<concurrent>指定是否并发执行查询和计数语句，请参考ScanAndCount。
+//
+// Eg:
+//
+// total, err := db.Model("user").Where("status", 1).Order("id desc").Paginate(1, 20, &users)
+func (m *Model) Paginate(page, size int, pointer interface{}, concurrent ...bool) (total int, err error) {
+	return m.Page(page, size).ScanAndCount(pointer, concurrent...)
+}
+
+// ScanAndCount 使用当前模型的条件查询记录并转换到<pointer>，同时返回忽略排序和分页时的记录总数<total>，
+// 参数<pointer>的类型同Scan。
+//
+// 查询和计数语句在同一个数据库节点的连接池（事务中则为当前事务）上执行，以免主从节点的数据延迟导致记录与总数不一致，
+// 但不保证使用同一个连接。计数语句使用与查询语句相同的缓存设置，如果设置了缓存名称，计数语句的缓存名称会添加“@count”后缀。
+//
+// 可选参数<concurrent>指定是否并发执行查询和计数语句，在事务中执行时该参数无效。
+func (m *Model) ScanAndCount(pointer interface{}, concurrent ...bool) (total int, err error) {
+	model := m.Clone()
+	if model.getTX() == nil {
+		// It pins the node of the connection pool, but not a single connection of the pool.
+		model.link = model.getLink(false)
+	}
+	countModel := model.Clone()
+	if countModel.cacheName != "" {
+		countModel.cacheName += "@count"
+	}
+	if len(concurrent) > 0 && concurrent[0] && model.getTX() == nil {
+		var (
+			wg       sync.WaitGroup
+			countErr error
+		)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			total, countErr = countModel.Count()
+		}()
+		err = model.Scan(pointer)
+		wg.Wait()
+		if err == nil {
+			err = countErr
+		}
+		return total, err
+	}
+	if total, err = countModel.Count(); err != nil {
+		return 0, err
+	}
+	return total, model.Scan(pointer)
+}
@@ -0,0 +1,160 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"reflect"
+
+	"github.com/gogf/gf/errors/gerror"
+	"github.com/gogf/gf/util/gconv"
+)
+
+// Attach 在中间表中添加实体<pointer>的多对多关联属性<attrName>与关联键值<relatedKeys>的关联记录，已存在的关联记录将被忽略。
+//
+// 关联属性需要通过`orm:"with:id=role_id, pivot:user_role, pivot_key:uid=id"`标签声明中间表，所有操作在事务中执行。
+//
+// Eg:
+//
+// db.Model("user").Attach(user, "Roles", 1, 2, 3)
+func (m *Model) Attach(pointer interface{}, attrName string, relatedKeys ...interface{}) error {
+	relation, localValue, err := m.getPivotRelation(pointer, attrName)
+	if err != nil {
+		return err
+	}
+	return m.doPivotTransaction(func(tx *TX) error {
+		existing, err := m.getPivotRelatedKeySet(tx, relation, localValue)
+		if err != nil {
+			return err
+		}
+		return m.doPivotInsert(tx, relation, localValue, existing, relatedKeys)
+	})
+}
+
+// Detach 从中间表中删除实体<pointer>的多对多关联属性<attrName>与关联键值<relatedKeys>的关联记录，
+// 如果没有指定<relatedKeys>，则删除该实体的所有关联记录。
+func (m *Model) Detach(pointer interface{}, attrName string, relatedKeys ...interface{}) error {
+	relation, localValue, err := m.getPivotRelation(pointer, attrName)
+	if err != nil {
+		return err
+	}
+	return m.doPivotTransaction(func(tx *TX) error {
+		return m.doPivotDelete(tx, relation, localValue, relatedKeys)
+	})
+}
+
+// Sync 同步中间表中实体<pointer>的多对多关联属性<attrName>的关联记录，使其与关联键值<relatedKeys>一致:
+// 不在<relatedKeys>中的关联记录将被删除，缺少的关联记录将被添加。
+func (m *Model) Sync(pointer interface{}, attrName string, relatedKeys ...interface{}) error {
+	relation, localValue, err := m.getPivotRelation(pointer, attrName)
+	if err != nil {
+		return err
+	}
+	return m.doPivotTransaction(func(tx *TX) error {
+		existing, err := m.getPivotRelatedKeySet(tx, relation, localValue)
+		if err != nil {
+			return err
+		}
+		keySet := make(map[string]struct{}, len(relatedKeys))
+		for _, key := range relatedKeys {
+			keySet[gconv.String(key)] = struct{}{}
+		}
+		deletingKeys := make([]interface{}, 0)
+		for k, v := range existing {
+			if _, ok := keySet[k]; !ok {
+				deletingKeys = append(deletingKeys, v)
+				delete(existing, k)
+			}
+		}
+		if len(deletingKeys) > 0 {
+			if err = m.doPivotDelete(tx, relation, localValue, deletingKeys); err != nil {
+				return err
+			}
+		}
+		return m.doPivotInsert(tx, relation, localValue, existing, relatedKeys)
+	})
+}
+
+// getPivotRelation 返回实体<pointer>的多对多关联属性<attrName>声明的关联关系，以及实体在当前表中的关联键值。
+func (m *Model) getPivotRelation(pointer interface{}, attrName string) (relation withRelation, localValue interface{}, err error) {
+	reflectValue := reflect.ValueOf(pointer)
+	for reflectValue.Kind() == reflect.Ptr {
+		reflectValue = reflectValue.Elem()
+	}
+	if reflectValue.Kind() != reflect.Struct {
+		return relation, nil, gerror.Newf("parameter should be type of struct/*struct, but got: %v", reflectValue.Kind())
+	}
+	structType := reflectValue.Type()
+	field, ok := structType.FieldByName(attrName)
+	if !ok {
+		return relation, nil, gerror.Newf(`cannot find attribute with name "%s"`, attrName)
+	}
+	if relation, ok = parseWithRelation(field.Tag.Get(OrmTagForStruct)); !ok || relation.pivotTable == "" {
+		return relation, nil, gerror.Newf(`attribute "%s" is not a many-to-many relation with pivot table`, attrName)
+	}
+	localAttrName, ok := getStructAttrNameByColumn(structType, relation.localKey)
+	if !ok {
+		return relation, nil, gerror.Newf(`cannot find attribute for field "%s"`, relation.localKey)
+	}
+	return relation, reflectValue.FieldByName(localAttrName).Interface(), nil
+}
+
+// getPivotRelatedKeySet 查询并返回中间表中当前实体已关联的关联键值，键名为关联键值的字符串形式。
+func (m *Model) getPivotRelatedKeySet(tx *TX, relation withRelation, localValue interface{}) (map[string]interface{}, error) {
+	array, err := tx.Model(relation.pivotTable).
+		Fields(relation.pivotForeignKey).
+		Where(relation.pivotLocalKey, localValue).
+		Array()
+	if err != nil {
+		return nil, err
+	}
+	keySet := make(map[string]interface{}, len(array))
+	for _, v := range array {
+		keySet[v.String()] = v.Val()
+	}
+	return keySet, nil
+}
+
+// doPivotInsert 在中间表中添加<relatedKeys>中不存在于<existing>的关联记录。
+func (m *Model) doPivotInsert(
+	tx *TX, relation withRelation, localValue interface{}, existing map[string]interface{}, relatedKeys []interface{},
+) error {
+	list := make(List, 0, len(relatedKeys))
+	for _, key := range relatedKeys {
+		k := gconv.String(key)
+		if _, ok := existing[k]; ok {
+			continue
+		}
+		existing[k] = key
+		list = append(list, Map{
+			relation.pivotLocalKey:   localValue,
+			relation.pivotForeignKey: key,
+		})
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	_, err := tx.Model(relation.pivotTable).Data(list).Insert()
+	return err
+}
+
+// doPivotDelete 从中间表中删除当前实体与<relatedKeys>的关联记录，<relatedKeys>为空时删除当前实体的所有关联记录。
+func (m *Model) doPivotDelete(tx *TX, relation withRelation, localValue interface{}, relatedKeys []interface{}) error {
+	model := tx.Model(relation.pivotTable).Where(relation.pivotLocalKey, localValue)
+	if len(relatedKeys) > 0 {
+		model = model.Where(relation.pivotForeignKey, relatedKeys)
+	}
+	_, err := model.Delete()
+	return err
+}
+
+// doPivotTransaction 在事务中执行中间表的维护操作，如果当前模型已经在事务中，则使用嵌套事务。
+func (m *Model) doPivotTransaction(f func(tx *TX) error) error {
+	if tx := m.getTX(); tx != nil {
+		return tx.Transaction(f)
+	}
+	return m.db.Transaction(f)
+}
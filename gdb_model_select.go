@@ -12,9 +12,17 @@ import (
 	"github.com/gogf/gf/container/gvar"
 	"github.com/gogf/gf/internal/intlog"
 	"github.com/gogf/gf/internal/json"
+	"github.com/gogf/gf/text/gregex"
 	"github.com/gogf/gf/text/gstr"
 	"github.com/gogf/gf/util/gconv"
 	"reflect"
+	"strings"
+)
+
+const (
+	aggregateField      = "aggregate_value" // 聚合统计结果的字段别名。
+	aggregateAlias      = "aggregate_alias" // 分组聚合统计时子查询的别名。
+	aggregateCountField = "aggregate_count" // 分组统计平均值时各分组记录数的字段别名。
 )
 
 // All 对model执行“select from...”语句，它从表中检索记录，并以切片类型返回结果。
@@ -38,9 +46,34 @@ func (m *Model) doGetAll(limit1 bool, where ...interface{}) (Result, error) {
 		return m.Where(where[0], where[1:]...).All()
 	}
 	var (
-		softDeletingCondition                         = m.getConditionForSoftDeleting()
-		conditionWhere, conditionExtra, conditionArgs = m.formatCondition(limit1, false)
+		conditionWhere, conditionExtra, conditionArgs = m.getSelectCondition(limit1, false)
+		sqlWithHolder, holderArgs                     = m.formatSelectSqlAndArgs(conditionWhere+conditionExtra, conditionArgs)
 	)
+	sqlWithHolder, holderArgs, err := m.withCteSql(sqlWithHolder, holderArgs)
+	if err != nil {
+		return nil, err
+	}
+	result, err := m.doGetAllBySql(sqlWithHolder, holderArgs...)
+	if err != nil {
+		return result, err
+	}
+	if handlers := m.getHookHandlers(); len(handlers) > 0 {
+		hookInput := m.newHookInput()
+		hookInput.Condition = conditionWhere + conditionExtra
+		hookInput.Args = conditionArgs
+		hookInput.Result = result
+		if err = m.callHooks(hookEventAfterSelect, handlers, hookInput); err != nil {
+			return nil, err
+		}
+		result = hookInput.Result
+	}
+	return result, nil
+}
+
+// getSelectCondition 返回查询语句的条件、附加语句及其参数，包含软删除的条件。
+func (m *Model) getSelectCondition(limit1 bool, isCount bool) (conditionWhere string, conditionExtra string, conditionArgs []interface{}) {
+	softDeletingCondition := m.getConditionForSoftDeleting()
+	conditionWhere, conditionExtra, conditionArgs = m.formatCondition(limit1, isCount)
 	if !m.unscoped && softDeletingCondition != "" {
 		if conditionWhere == "" {
 			conditionWhere = " WHERE "
@@ -49,18 +82,66 @@ func (m *Model) doGetAll(limit1 bool, where ...interface{}) (Result, error) {
 		}
 		conditionWhere += softDeletingCondition
 	}
+	return
+}
 
+// getSelectSqlAndArgs 返回带有占位符的查询语句及其参数，参数按照字段、表名、条件中出现的顺序合并。
+func (m *Model) getSelectSqlAndArgs(limit1 bool) (sqlWithHolder string, holderArgs []interface{}) {
+	conditionWhere, conditionExtra, conditionArgs := m.getSelectCondition(limit1, false)
+	return m.formatSelectSqlAndArgs(conditionWhere+conditionExtra, conditionArgs)
+}
+
+// formatSelectSqlAndArgs 使用已生成的查询条件<condition>及其参数<conditionArgs>返回带有占位符的查询语句及其参数。
+func (m *Model) formatSelectSqlAndArgs(condition string, conditionArgs []interface{}) (sqlWithHolder string, holderArgs []interface{}) {
 	// DO NOT quote the m.fields where, in case of fields like:
 	// DISTINCT t.user_id uid
-	return m.doGetAllBySql(
-		fmt.Sprintf(
-			"SELECT %s FROM %s%s",
-			m.getFieldsFiltered(),
-			m.tables,
-			conditionWhere+conditionExtra,
-		),
-		conditionArgs...,
+	sqlWithHolder = fmt.Sprintf(
+		"SELECT %s%s FROM %s%s",
+		m.getDistinctKeyword(),
+		m.getFieldsFiltered(),
+		m.tables,
+		condition,
+	)
+	holderArgs = make([]interface{}, 0, len(m.fieldsArgs)+len(m.tablesArgs)+len(conditionArgs))
+	holderArgs = append(holderArgs, m.fieldsArgs...)
+	holderArgs = append(holderArgs, m.tablesArgs...)
+	holderArgs = append(holderArgs, conditionArgs...)
+	return
+}
+
+// getDistinctKeyword 返回通过Distinct设置的去重关键字。
+func (m *Model) getDistinctKeyword() string {
+	if m.distinct {
+		return "DISTINCT "
+	}
+	return ""
+}
+
+// isDistinct 判断当前查询是否去重，包括通过Distinct设置或者在字段中使用“DISTINCT”关键字。
+func (m *Model) isDistinct() bool {
+	return m.distinct || gregex.IsMatchString(`^\s*(?i)DISTINCT\s`, m.fields)
+}
+
+// getSubQuery 返回当前模型作为子查询时的语句及其参数，语句使用括号包裹。
+// 如果当前模型通过As设置了别名，别名将作为子查询的别名，如:
+//
+// db.Model("user").Where("status", 1).As("u") 构造为 (SELECT * FROM `user` WHERE `status`=?) AS `u`
+func (m *Model) getSubQuery() (subQuery string, subArgs []interface{}) {
+	var (
+		model = m
+		alias = ""
 	)
+	if m.alias != "" && gstr.HasSuffix(m.tables, " AS "+m.alias) {
+		alias = m.alias
+		model = m.Clone()
+		model.tables = gstr.TrimRightStr(model.tables, " AS "+alias, 1)
+	}
+	subQuery, subArgs = model.getSelectSqlAndArgs(false)
+	subQuery = fmt.Sprintf(`(%s)`, subQuery)
+	if alias != "" {
+		subQuery = fmt.Sprintf(`%s AS %s`, subQuery, m.db.QuoteWord(alias))
+	}
+	return
 }
 
 // getFieldsFiltered 检查字段和fieldsEx属性，筛选并返回将真正提交给底层数据库驱动程序的字段。
@@ -220,7 +301,10 @@ func (m *Model) Struct(pointer interface{}, where ...interface{}) error {
 	if err != nil {
 		return err
 	}
-	return one.Struct(pointer)
+	if err = one.Struct(pointer); err != nil {
+		return err
+	}
+	return m.doWithScanStruct(pointer)
 }
 
 // Structs 从表中检索记录并将其转换为给定的结构体切片，
@@ -240,7 +324,10 @@ func (m *Model) Structs(pointer interface{}, where ...interface{}) error {
 	if err != nil {
 		return err
 	}
-	return all.Structs(pointer)
+	if err = all.Structs(pointer); err != nil {
+		return err
+	}
+	return m.doWithScanStructs(pointer)
 }
 
 // Scan 根据参数<pointer>的类型自动调用Struct或Structs函数。
@@ -310,36 +397,45 @@ func (m *Model) ScanList(listPointer interface{}, attributeName string, relation
 	return all.ScanList(listPointer, attributeName, relation...)
 }
 
-// Count 对Model执行 "select count(x) from ..."语句。
+// Count 对Model执行 "select count(1) from ..."语句。
+//
+// 如果查询去重（通过Distinct设置或者字段中包含“DISTINCT”关键字），则统计去重后的记录数；
+// 如果设置了分组，则统计分组的数量。统计某个字段值不为NULL的记录数请使用CountColumn。
+//
 // 可选参数<where>与Model.Where()的参数相同。
 func (m *Model) Count(where ...interface{}) (int, error) {
 	if len(where) > 0 {
 		return m.Where(where[0], where[1:]...).Count()
 	}
-	countFields := "COUNT(1)"
-	if m.fields != "" && m.fields != "*" {
-		// DO NOT quote the m.fields here, in case of fields like:
-		// DISTINCT t.user_id uid
-		countFields = fmt.Sprintf(`COUNT(%s)`, m.fields)
-	}
 	var (
-		softDeletingCondition                         = m.getConditionForSoftDeleting()
-		conditionWhere, conditionExtra, conditionArgs = m.formatCondition(false, true)
+		isDistinct                                    = m.isDistinct()
+		conditionWhere, conditionExtra, conditionArgs = m.getSelectCondition(false, true)
+		s                                             string
 	)
-	if !m.unscoped && softDeletingCondition != "" {
-		if conditionWhere == "" {
-			conditionWhere = " WHERE "
-		} else {
-			conditionWhere += " AND "
+	if isDistinct {
+		// 去重查询作为子查询进行统计，以支持多个字段以及带有别名的字段，如:
+		// DISTINCT t.user_id uid, t.type
+		s = fmt.Sprintf(
+			"SELECT COUNT(1) FROM (SELECT %s%s FROM %s%s) count_alias",
+			m.getDistinctKeyword(), m.getFieldsFiltered(), m.tables, conditionWhere+conditionExtra,
+		)
+	} else {
+		s = fmt.Sprintf("SELECT COUNT(1) FROM %s%s", m.tables, conditionWhere+conditionExtra)
+		if len(m.groupBy) > 0 {
+			s = fmt.Sprintf("SELECT COUNT(1) FROM (%s) count_alias", s)
 		}
-		conditionWhere += softDeletingCondition
 	}
-
-	s := fmt.Sprintf("SELECT %s FROM %s%s", countFields, m.tables, conditionWhere+conditionExtra)
-	if len(m.groupBy) > 0 {
-		s = fmt.Sprintf("SELECT COUNT(1) FROM (%s) count_alias", s)
+	args := make([]interface{}, 0, len(m.fieldsArgs)+len(m.tablesArgs)+len(conditionArgs))
+	if isDistinct {
+		args = append(args, m.fieldsArgs...)
 	}
-	list, err := m.doGetAllBySql(s, conditionArgs...)
+	args = append(args, m.tablesArgs...)
+	args = append(args, conditionArgs...)
+	s, args, err := m.withCteSql(s, args)
+	if err != nil {
+		return 0, err
+	}
+	list, err := m.doGetAllBySql(s, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -351,6 +447,114 @@ func (m *Model) Count(where ...interface{}) (int, error) {
 	return 0, nil
 }
 
+// CountColumn 对Model执行 "select count(column) from ..."语句，统计字段<column>值不为NULL的记录数。
+func (m *Model) CountColumn(column string) (int, error) {
+	value, err := m.doAggregate("COUNT", column)
+	if err != nil {
+		return 0, err
+	}
+	return value.Int(), nil
+}
+
+// Sum 对Model执行 "select sum(column) from ..."语句，没有匹配的记录时返回0。
+func (m *Model) Sum(column string) (float64, error) {
+	value, err := m.doAggregate("SUM", column)
+	if err != nil {
+		return 0, err
+	}
+	return value.Float64(), nil
+}
+
+// Avg 对Model执行 "select avg(column) from ..."语句，没有匹配的记录时返回0。
+func (m *Model) Avg(column string) (float64, error) {
+	value, err := m.doAggregate("AVG", column)
+	if err != nil {
+		return 0, err
+	}
+	return value.Float64(), nil
+}
+
+// Min 对Model执行 "select min(column) from ..."语句，没有匹配的记录时返回值为nil。
+func (m *Model) Min(column string) (Value, error) {
+	return m.doAggregate("MIN", column)
+}
+
+// Max 对Model执行 "select max(column) from ..."语句，没有匹配的记录时返回值为nil。
+func (m *Model) Max(column string) (Value, error) {
+	return m.doAggregate("MAX", column)
+}
+
+// doAggregate 对Model执行聚合函数<function>统计字段<column>，并返回统计结果。
+//
+// 如果通过Distinct设置了去重，则只对去重后的字段值进行统计，如: SUM(DISTINCT column)。
+//
+// 如果设置了分组，则统计的是满足分组及Having条件的所有分组中的全部记录，而不是各分组统计结果的统计：
+// 先按分组统计，再对各分组的统计结果进行汇总，COUNT/SUM的分组结果使用SUM汇总，MIN/MAX的分组结果使用MIN/MAX汇总，
+// AVG使用各分组的SUM及COUNT的汇总结果计算，以保证分组记录数不同时结果仍然正确。去重只在各分组内生效。
+func (m *Model) doAggregate(function string, column string) (Value, error) {
+	var (
+		conditionWhere, conditionExtra, conditionArgs = m.getSelectCondition(false, true)
+		field                                         = m.getDistinctKeyword() + m.db.QuoteString(column)
+		isGroupAvg                                    = len(m.groupBy) > 0 && function == "AVG"
+		s                                             string
+	)
+	if isGroupAvg {
+		s = fmt.Sprintf(
+			"SELECT SUM(%s) AS %s, COUNT(%s) AS %s FROM %s%s",
+			field, aggregateField, field, aggregateCountField, m.tables, conditionWhere+conditionExtra,
+		)
+		s = fmt.Sprintf(
+			"SELECT SUM(%s) AS %s, SUM(%s) AS %s FROM (%s) %s",
+			aggregateField, aggregateField, aggregateCountField, aggregateCountField, s, aggregateAlias,
+		)
+	} else {
+		s = fmt.Sprintf(
+			"SELECT %s(%s) AS %s FROM %s%s",
+			function, field, aggregateField, m.tables, conditionWhere+conditionExtra,
+		)
+		if len(m.groupBy) > 0 {
+			outerFunction := function
+			if function == "COUNT" {
+				outerFunction = "SUM"
+			}
+			s = fmt.Sprintf("SELECT %s(%s) FROM (%s) %s", outerFunction, aggregateField, s, aggregateAlias)
+		}
+	}
+	args := make([]interface{}, 0, len(m.tablesArgs)+len(conditionArgs))
+	args = append(args, m.tablesArgs...)
+	args = append(args, conditionArgs...)
+	s, args, err := m.withCteSql(s, args)
+	if err != nil {
+		return gvar.New(nil), err
+	}
+	list, err := m.doGetAllBySql(s, args...)
+	if err != nil {
+		return gvar.New(nil), err
+	}
+	if len(list) == 0 {
+		return gvar.New(nil), nil
+	}
+	if isGroupAvg {
+		// 部分数据库(如oracle)返回的字段名称为大写，因此这里不区分大小写。
+		var sum, count float64
+		for k, v := range list[0] {
+			if strings.EqualFold(k, aggregateCountField) {
+				count = v.Float64()
+			} else {
+				sum = v.Float64()
+			}
+		}
+		if count == 0 {
+			return gvar.New(nil), nil
+		}
+		return gvar.New(sum / count), nil
+	}
+	for _, v := range list[0] {
+		return v, nil
+	}
+	return gvar.New(nil), nil
+}
+
 // FindOne 通过M.WherePri和M.One检索并返回单个记录。
 func (m *Model) FindOne(where ...interface{}) (Record, error) {
 	if len(where) > 0 {
@@ -409,10 +613,13 @@ func (m *Model) FindScan(pointer interface{}, where ...interface{}) error {
 
 // doGetAllBySql 对数据库执行select语句。
 func (m *Model) doGetAllBySql(sql string, args ...interface{}) (result Result, err error) {
+	if m.err != nil {
+		return nil, m.err
+	}
 	cacheKey := ""
 	cacheObj := m.db.GetCache().Ctx(m.db.GetCtx())
 	// Retrieve from cache.
-	if m.cacheEnabled && m.tx == nil {
+	if m.cacheEnabled && m.getTX() == nil {
 		cacheKey = m.cacheName
 		if len(cacheKey) == 0 {
 			cacheKey = sql + ", @PARAMS:" + gconv.String(args)
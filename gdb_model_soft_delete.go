@@ -0,0 +1,237 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/gogf/gf/errors/gerror"
+	"github.com/gogf/gf/os/gtime"
+	"github.com/gogf/gf/text/gstr"
+)
+
+// SoftDeleteStrategy 是软删除策略接口，决定软删除字段写入的值以及查询时使用的条件。
+type SoftDeleteStrategy interface {
+	// DeletedValue 返回软删除记录时写入软删除字段的值，参数<now>是使用配置的时区的当前时间。
+	DeletedValue(now *gtime.Time) interface{}
+
+	// LiveValue 返回未删除记录的软删除字段的值，写入记录及恢复记录时使用，nil表示NULL。
+	LiveValue() interface{}
+
+	// Condition 返回软删除字段<field>的查询条件，<field>已经过转义并可能带有表名前缀。
+	// 参数<deleted>为true时返回已删除记录的条件，否则返回未删除记录的条件。
+	Condition(field string, deleted bool) string
+}
+
+const (
+	SoftDeleteDatetime  = "datetime"  // 软删除时写入日期时间，未删除的记录为NULL。
+	SoftDeleteUnix      = "unix"      // 软删除时写入秒级时间戳，未删除的记录为0。
+	SoftDeleteUnixMilli = "unixmilli" // 软删除时写入毫秒级时间戳，未删除的记录为0。
+	SoftDeleteFlag      = "flag"      // 软删除时写入1，未删除的记录为0。
+	SoftDeleteBool      = "bool"      // 软删除时写入TRUE，未删除的记录为FALSE或者NULL，用于布尔类型的字段。
+)
+
+var (
+	// softDeleteStrategyMap 管理所有内置及自定义注册的软删除策略。
+	softDeleteStrategyMap = map[string]SoftDeleteStrategy{
+		SoftDeleteDatetime:  softDeleteDatetime{},
+		SoftDeleteUnix:      softDeleteNumeric{deletedValue: func(now *gtime.Time) interface{} { return now.Timestamp() }},
+		SoftDeleteUnixMilli: softDeleteNumeric{deletedValue: func(now *gtime.Time) interface{} { return now.TimestampMilli() }},
+		SoftDeleteFlag:      softDeleteNumeric{deletedValue: func(now *gtime.Time) interface{} { return 1 }},
+		SoftDeleteBool:      softDeleteBool{},
+	}
+)
+
+// RegisterSoftDeleteStrategy 注册自定义的软删除策略，注册后可以通过配置的SoftDeleteStrategy使用该策略，
+// 同名的策略将会被覆盖，包括内置的策略。该方法不是并发安全的，应当在程序初始化时调用。
+func RegisterSoftDeleteStrategy(name string, strategy SoftDeleteStrategy) error {
+	if strategy == nil {
+		return gerror.Newf(`invalid soft delete strategy "%s": nil`, name)
+	}
+	softDeleteStrategyMap[name] = strategy
+	return nil
+}
+
+// softDeleteDatetime 是使用日期时间的软删除策略。
+type softDeleteDatetime struct{}
+
+func (softDeleteDatetime) DeletedValue(now *gtime.Time) interface{} {
+	return now.String()
+}
+
+func (softDeleteDatetime) LiveValue() interface{} {
+	return nil
+}
+
+func (softDeleteDatetime) Condition(field string, deleted bool) string {
+	if deleted {
+		return fmt.Sprintf(`%s IS NOT NULL`, field)
+	}
+	return fmt.Sprintf(`%s IS NULL`, field)
+}
+
+// softDeleteNumeric 是使用数值的软删除策略，未删除的记录值为0，如时间戳或者删除标记。
+type softDeleteNumeric struct {
+	deletedValue func(now *gtime.Time) interface{}
+}
+
+func (s softDeleteNumeric) DeletedValue(now *gtime.Time) interface{} {
+	return s.deletedValue(now)
+}
+
+func (s softDeleteNumeric) LiveValue() interface{} {
+	return 0
+}
+
+func (s softDeleteNumeric) Condition(field string, deleted bool) string {
+	if deleted {
+		return fmt.Sprintf(`%s<>0`, field)
+	}
+	return fmt.Sprintf(`%s=0`, field)
+}
+
+// softDeleteTime 是写入删除时间的软删除策略，删除时间的值由<deletedValue>决定，未删除的记录值及条件与内嵌的策略相同。
+type softDeleteTime struct {
+	SoftDeleteStrategy
+	deletedValue func(now *gtime.Time) interface{}
+}
+
+func (s softDeleteTime) DeletedValue(now *gtime.Time) interface{} {
+	return s.deletedValue(now)
+}
+
+// softDeleteBool 是使用布尔值的软删除策略，布尔类型(如pgsql的boolean)不能与数值比较，因此使用IS TRUE判断。
+type softDeleteBool struct{}
+
+func (softDeleteBool) DeletedValue(now *gtime.Time) interface{} {
+	return true
+}
+
+func (softDeleteBool) LiveValue() interface{} {
+	return false
+}
+
+func (softDeleteBool) Condition(field string, deleted bool) string {
+	if deleted {
+		return fmt.Sprintf(`%s IS TRUE`, field)
+	}
+	return fmt.Sprintf(`%s IS NOT TRUE`, field)
+}
+
+// OnlyTrashed 设置查询只返回已被软删除的记录，对于关联查询只作用于主表，
+// 使用Unscoped时该设置无效。
+func (m *Model) OnlyTrashed() *Model {
+	model := m.getModel()
+	model.onlyTrashed = true
+	return model
+}
+
+// Restore 恢复满足条件的已被软删除的记录，即将软删除字段的值重置为未删除记录的值。
+//
+// 可选参数<where>与Model.Where()的参数相同，表中不存在软删除字段时返回错误。
+func (m *Model) Restore(where ...interface{}) (result sql.Result, err error) {
+	if len(where) > 0 {
+		return m.Where(where[0], where[1:]...).Restore()
+	}
+	fieldNameDelete := m.getSoftFieldNameDeleted()
+	if fieldNameDelete == "" {
+		return nil, gerror.New("there should be soft deleting field in the table for RESTORE operation")
+	}
+	if len(m.tablesArgs) > 0 {
+		return nil, gerror.New("sub-query with arguments in table is not supported for RESTORE operation")
+	}
+	var (
+		strategy                                      = m.getSoftDeleteStrategy(m.getPrimaryTableName(), fieldNameDelete)
+		deletedCondition                              = strategy.Condition(m.db.QuoteWord(fieldNameDelete), true)
+		conditionWhere, conditionExtra, conditionArgs = m.formatCondition(false, false)
+	)
+	if conditionWhere == "" {
+		conditionWhere = " WHERE " + deletedCondition
+	} else {
+		conditionWhere = fmt.Sprintf(
+			` WHERE (%s) AND %s`, gstr.TrimLeftStr(conditionWhere, " WHERE "), deletedCondition,
+		)
+	}
+	defer func() {
+		if err == nil {
+			m.checkAndRemoveCache()
+		}
+	}()
+	return m.db.DoUpdate(
+		m.getLink(true),
+		m.tables,
+		Map{fieldNameDelete: strategy.LiveValue()},
+		conditionWhere+conditionExtra,
+		conditionArgs...,
+	)
+}
+
+// getSoftDeleteStrategy 返回表<table>的软删除字段<field>使用的软删除策略。
+//
+// 优先使用配置的SoftDeleteStrategy中该表对应的策略，表名可以带有或者不带表前缀，
+// 未配置或者策略不存在时根据字段的类型自动选择。
+func (m *Model) getSoftDeleteStrategy(table, field string) SoftDeleteStrategy {
+	if strategies := m.db.GetConfig().SoftDeleteStrategy; len(strategies) > 0 {
+		charL, charR := m.db.GetChars()
+		name := gstr.Trim(table, charL+charR)
+		strategyName, ok := strategies[name]
+		if !ok && m.db.GetPrefix() != "" {
+			strategyName, ok = strategies[gstr.TrimLeftStr(name, m.db.GetPrefix())]
+		}
+		if strategy, ok := softDeleteStrategyMap[strategyName]; ok {
+			return strategy
+		}
+	}
+	fieldsMap, _ := m.db.TableFields(table)
+	if tableField, ok := fieldsMap[field]; ok {
+		name := getSoftDeleteStrategyNameByFieldType(tableField.Type)
+		switch name {
+		case SoftDeleteDatetime, SoftDeleteUnix, SoftDeleteUnixMilli:
+			return m.getSoftDeleteTimeStrategy(softDeleteStrategyMap[name], table, field)
+		}
+		return softDeleteStrategyMap[name]
+	}
+	return softDeleteStrategyMap[SoftDeleteDatetime]
+}
+
+// getSoftDeleteTimeStrategy 返回自动选择的时间类型的软删除策略<strategy>，内置的策略与自动维护的创建/更新时间一样
+// 根据字段的类型及配置的TimePrecision写入删除时间，通过RegisterSoftDeleteStrategy覆盖的策略保持不变。
+func (m *Model) getSoftDeleteTimeStrategy(strategy SoftDeleteStrategy, table, field string) SoftDeleteStrategy {
+	switch strategy.(type) {
+	case softDeleteDatetime, softDeleteNumeric:
+		return softDeleteTime{
+			SoftDeleteStrategy: strategy,
+			deletedValue: func(now *gtime.Time) interface{} {
+				return m.getSoftTimeValue(table, field, now)
+			},
+		}
+	}
+	return strategy
+}
+
+// getSoftDeleteStrategyNameByFieldType 根据字段类型返回默认的软删除策略名称:
+// tinyint/smallint/bit等类型使用删除标记，bool/boolean类型使用布尔值，int/integer等类型使用秒级时间戳，
+// bigint等类型使用毫秒级时间戳，其他类型使用日期时间。
+func getSoftDeleteStrategyNameByFieldType(fieldType string) string {
+	t := strings.ToLower(fieldType)
+	if i := strings.IndexAny(t, "( "); i != -1 {
+		t = t[:i]
+	}
+	switch t {
+	case "tinyint", "smallint", "int2", "bit":
+		return SoftDeleteFlag
+	case "bool", "boolean":
+		return SoftDeleteBool
+	case "int", "integer", "mediumint", "int4", "number":
+		return SoftDeleteUnix
+	case "bigint", "int8":
+		return SoftDeleteUnixMilli
+	}
+	return SoftDeleteDatetime
+}
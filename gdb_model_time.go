@@ -9,6 +9,8 @@ package gdb
 import (
 	"fmt"
 	"github.com/gogf/gf/container/garray"
+	"github.com/gogf/gf/internal/intlog"
+	"github.com/gogf/gf/os/gtime"
 	"github.com/gogf/gf/text/gregex"
 	"github.com/gogf/gf/text/gstr"
 	"github.com/gogf/gf/util/gconv"
@@ -21,6 +23,11 @@ var (
 	deletedFiledNames = []string{"deleted_at", "delete_at"} // Default filed names of table for automatic-filled deleted datetime.
 )
 
+const (
+	TimePrecisionSecond      = "second"      // 自动维护的时间精确到秒。
+	TimePrecisionMillisecond = "millisecond" // 自动维护的时间精确到毫秒。
+)
+
 // Unscoped 禁用: 插入/更新/删除操作的自动更新时间功能。
 func (m *Model) Unscoped() *Model {
 	model := m.getModel()
@@ -28,6 +35,65 @@ func (m *Model) Unscoped() *Model {
 	return model
 }
 
+// getSoftTime 返回自动维护时间使用的当前时间，如果配置了TimeZone则转换为该时区的时间。
+func (m *Model) getSoftTime() *gtime.Time {
+	now := gtime.Now()
+	if zone := m.db.GetConfig().TimeZone; zone != "" {
+		if t, err := now.ToZone(zone); err == nil {
+			return t
+		} else {
+			intlog.Error(err)
+		}
+	}
+	return now
+}
+
+// getSoftTimeValue 根据表<table>的字段<field>的类型将时间<now>转换为自动维护时写入的值:
+// int/integer等类型写入秒级时间戳，bigint等类型写入毫秒级时间戳，不包含时间部分的date类型写入日期，其他类型写入日期时间。
+//
+// 配置的TimePrecision优先于字段类型决定整数类型的时间戳及日期时间的精度，
+// 未配置时datetime(3)等带有小数秒精度的类型写入精确到毫秒的日期时间。
+func (m *Model) getSoftTimeValue(table, field string, now *gtime.Time) interface{} {
+	var (
+		fieldType    string
+		precision    = m.db.GetConfig().TimePrecision
+		fieldsMap, _ = m.db.TableFields(table)
+	)
+	if tableField, ok := fieldsMap[field]; ok {
+		fieldType = gstr.ToLower(tableField.Type)
+	}
+	baseType, _ := gregex.ReplaceString(`\(.+\)`, "", fieldType)
+	if array := gstr.SplitAndTrim(baseType, " "); len(array) > 0 {
+		baseType = array[0]
+	}
+	switch baseType {
+	case "int", "integer", "mediumint", "int4", "number":
+		if precision == TimePrecisionMillisecond {
+			return now.TimestampMilli()
+		}
+		return now.Timestamp()
+
+	case "bigint", "int8":
+		if precision == TimePrecisionSecond {
+			return now.Timestamp()
+		}
+		return now.TimestampMilli()
+
+	case "date":
+		// oracle等数据库的DATE类型包含时间部分，写入日期时间。
+		if v, ok := m.db.(interface{ isDateWithTime() bool }); !ok || !v.isDateWithTime() {
+			return now.Format("Y-m-d")
+		}
+	}
+	if precision == "" && gregex.IsMatchString(`^(datetime|timestamp)\([1-9]\)`, fieldType) {
+		precision = TimePrecisionMillisecond
+	}
+	if precision == TimePrecisionMillisecond {
+		return now.Format("Y-m-d H:i:s.u")
+	}
+	return now.String()
+}
+
 // getSoftFieldNameCreate 检查并返回创建记录的时间的字段名。
 // 如果没有用于存储创建时间的字段名，则返回空字符串。它检查是否有大小写或字符“-'/'.'/'.'/''。
 //
@@ -85,7 +151,7 @@ func (m *Model) getSoftFieldNameDeleted(table ...string) (field string) {
 		tableName = m.getPrimaryTableName()
 	}
 	config := m.db.GetConfig()
-	if config.UpdatedAt != "" {
+	if config.DeletedAt != "" {
 		return m.getSoftFieldName(tableName, []string{config.DeletedAt})
 	}
 	return m.getSoftFieldName(tableName, deletedFiledNames)
@@ -93,6 +159,10 @@ func (m *Model) getSoftFieldNameDeleted(table ...string) (field string) {
 
 // getSoftFieldName 检索并返回可能键的表的字段名。
 func (m *Model) getSoftFieldName(table string, keys []string) (field string) {
+	// 子查询及公共表表达式没有对应的表结构，其自身的软删除条件已在其查询内部处理。
+	if gstr.HasPrefix(table, "(") || m.isCteTable(table) {
+		return ""
+	}
 	fieldsMap, _ := m.db.TableFields(table)
 	if len(fieldsMap) > 0 {
 		for _, key := range keys {
@@ -113,25 +183,32 @@ func (m *Model) getSoftFieldName(table string, keys []string) (field string) {
 // "user u LEFT JOIN user_detail ud ON(ud.uid=u.uid)"
 // "user LEFT JOIN user_detail ON(user_detail.uid=user.uid)"
 // "user u LEFT JOIN user_detail ud ON(ud.uid=u.uid) LEFT JOIN user_stats us ON(us.uid=u.uid)"
+// "(SELECT * FROM user WHERE deleted_at IS NULL) AS u LEFT JOIN user_detail ud ON(ud.uid=u.uid)"
+//
+// Sub query tables are ignored here as their own soft deleting condition is added inside the sub query.
+// The condition of the deleted records is used for the base table if OnlyTrashed is set.
 func (m *Model) getConditionForSoftDeleting() string {
 	if m.unscoped {
 		return ""
 	}
-	conditionArray := garray.NewStrArray()
-	if gstr.Contains(m.tables, " JOIN ") {
+	var (
+		tables         = replaceSubQuery(m.tables, func(subQuery string) string { return "SUB_QUERY" })
+		conditionArray = garray.NewStrArray()
+	)
+	if gstr.Contains(tables, " JOIN ") {
 		// Base table.
-		match, _ := gregex.MatchString(`(.+?) [A-Z]+ JOIN`, m.tables)
-		conditionArray.Append(m.getConditionOfTableStringForSoftDeleting(match[1]))
+		match, _ := gregex.MatchString(`(.+?) [A-Z]+ JOIN`, tables)
+		conditionArray.Append(m.getConditionOfTableStringForSoftDeleting(match[1], m.onlyTrashed))
 		// Multiple joined tables, exclude the sub query sql which contains char '(' and ')'.
-		matches, _ := gregex.MatchAllString(`JOIN ([^()]+?) ON`, m.tables)
+		matches, _ := gregex.MatchAllString(`JOIN ([^()]+?) ON`, tables)
 		for _, match := range matches {
-			conditionArray.Append(m.getConditionOfTableStringForSoftDeleting(match[1]))
+			conditionArray.Append(m.getConditionOfTableStringForSoftDeleting(match[1], false))
 		}
 	}
-	if conditionArray.Len() == 0 && gstr.Contains(m.tables, ",") {
+	if conditionArray.Len() == 0 && gstr.Contains(tables, ",") {
 		// Multiple base tables.
-		for _, s := range gstr.SplitAndTrim(m.tables, ",") {
-			conditionArray.Append(m.getConditionOfTableStringForSoftDeleting(s))
+		for i, s := range gstr.SplitAndTrim(tables, ",") {
+			conditionArray.Append(m.getConditionOfTableStringForSoftDeleting(s, m.onlyTrashed && i == 0))
 		}
 	}
 	conditionArray.FilterEmpty()
@@ -140,13 +217,16 @@ func (m *Model) getConditionForSoftDeleting() string {
 	}
 	// Only one table.
 	if fieldName := m.getSoftFieldNameDeleted(); fieldName != "" {
-		return fmt.Sprintf(`%s IS NULL`, m.db.QuoteWord(fieldName))
+		return m.getSoftDeleteStrategy(m.getPrimaryTableName(), fieldName).Condition(
+			m.db.QuoteWord(fieldName), m.onlyTrashed,
+		)
 	}
 	return ""
 }
 
 // getConditionOfTableStringForSoftDeleting does something as its name describes.
-func (m *Model) getConditionOfTableStringForSoftDeleting(s string) string {
+// The parameter <deleted> specifies whether returning the condition of the deleted records.
+func (m *Model) getConditionOfTableStringForSoftDeleting(s string, deleted bool) string {
 	var (
 		field  = ""
 		table  = ""
@@ -162,13 +242,18 @@ func (m *Model) getConditionOfTableStringForSoftDeleting(s string) string {
 	if field == "" {
 		return ""
 	}
+	var (
+		prefix   = table
+		strategy = m.getSoftDeleteStrategy(table, field)
+	)
 	if len(array1) >= 3 {
-		return fmt.Sprintf(`%s.%s IS NULL`, m.db.QuoteWord(array1[2]), m.db.QuoteWord(field))
-	}
-	if len(array1) >= 2 {
-		return fmt.Sprintf(`%s.%s IS NULL`, m.db.QuoteWord(array1[1]), m.db.QuoteWord(field))
+		prefix = array1[2]
+	} else if len(array1) >= 2 {
+		prefix = array1[1]
 	}
-	return fmt.Sprintf(`%s.%s IS NULL`, m.db.QuoteWord(table), m.db.QuoteWord(field))
+	return strategy.Condition(
+		fmt.Sprintf(`%s.%s`, m.db.QuoteWord(prefix), m.db.QuoteWord(field)), deleted,
+	)
 }
 
 // getPrimaryTableName parses and returns the primary table name.
@@ -0,0 +1,63 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"fmt"
+)
+
+const (
+	unionTypeNormal = "UNION"       // 组合查询并去除重复记录。
+	unionTypeAll    = "UNION ALL"   // 组合查询并保留重复记录。
+	unionAlias      = "union_alias" // 组合查询作为子查询时的别名。
+)
+
+// Union 使用“UNION”组合多个模型的查询结果，返回一个以组合查询为子查询的新模型，
+// 该模型可以继续进行条件过滤、排序、分页、计数以及结构体转换等操作，组合查询的别名为“union_alias”。
+//
+// 各模型的查询条件、软删除条件以及排序分页都会保留在各自的查询语句中，参数按照模型的顺序合并。
+//
+// Eg:
+//
+// db.Union(db.Model("user").Where("id", 1), db.Model("user").Where("id", 2)).Order("id desc").Limit(10).All()
+func (c *Core) Union(unions ...*Model) *Model {
+	return c.doUnion(unionTypeNormal, unions...)
+}
+
+// UnionAll 使用“UNION ALL”组合多个模型的查询结果，不去除重复的记录，其他同Union。
+func (c *Core) UnionAll(unions ...*Model) *Model {
+	return c.doUnion(unionTypeAll, unions...)
+}
+
+// doUnion 使用<unionType>组合多个模型的查询语句，并返回以组合查询为子查询的新模型。
+func (c *Core) doUnion(unionType string, unions ...*Model) *Model {
+	if len(unions) == 0 {
+		panic("组合查询的模型不能为空")
+	}
+	var (
+		sqlList    = make([]string, 0, len(unions))
+		tablesArgs = make([]interface{}, 0)
+	)
+	for _, v := range unions {
+		sqlWithHolder, holderArgs := v.getSelectSqlAndArgs(false)
+		sqlList = append(sqlList, sqlWithHolder)
+		tablesArgs = append(tablesArgs, holderArgs...)
+	}
+	// 部分数据库（如oracle）不支持使用“AS”声明子查询的别名。
+	tables := fmt.Sprintf(`(%s) %s`, c.DB.GetUnionSql(unionType, sqlList), c.DB.QuoteWord(unionAlias))
+	return &Model{
+		db:         c.DB,
+		tx:         unions[0].tx,
+		tablesInit: tables,
+		tables:     tables,
+		tablesArgs: tablesArgs,
+		fields:     "*",
+		start:      -1,
+		offset:     -1,
+		option:     OptionAllowEmpty,
+	}
+}
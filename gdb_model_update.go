@@ -10,7 +10,6 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/gogf/gf/errors/gerror"
-	"github.com/gogf/gf/os/gtime"
 	"github.com/gogf/gf/text/gstr"
 	"github.com/gogf/gf/util/gconv"
 	"github.com/gogf/gf/util/gutil"
@@ -30,26 +29,67 @@ func (m *Model) Update(dataAndWhere ...interface{}) (result sql.Result, err erro
 			return m.Data(dataAndWhere[0]).Update()
 		}
 	}
-	defer func() {
-		if err == nil {
-			m.checkAndRemoveCache()
-		}
-	}()
+	if m.err != nil {
+		return nil, m.err
+	}
 	if m.data == nil {
 		return nil, gerror.New("updating table with empty data")
 	}
+	// 更新数据的参数位于表名之后、条件之前，无法与表名中子查询的参数合并。
+	if len(m.tablesArgs) > 0 {
+		return nil, gerror.New("sub-query with arguments in table is not supported for UPDATE operation")
+	}
 	var (
 		updateData                                    = m.data
 		fieldNameCreate                               = m.getSoftFieldNameCreated()
 		fieldNameUpdate                               = m.getSoftFieldNameUpdated()
 		fieldNameDelete                               = m.getSoftFieldNameDeleted()
 		conditionWhere, conditionExtra, conditionArgs = m.formatCondition(false, false)
+		versionField                                  = m.getVersionField()
+		versionValue                                  interface{}
 	)
+	// 乐观锁，使用更新数据中的版本号作为附加的更新条件，没有更新条件时不附加以便拒绝更新操作。
+	if versionField != "" && conditionWhere != "" {
+		if versionValue = m.getVersionValue(versionField); versionValue != nil {
+			conditionWhere = fmt.Sprintf(
+				` WHERE (%s) AND %s=?`,
+				gstr.TrimLeftStr(conditionWhere, " WHERE "), m.db.QuoteWord(versionField),
+			)
+			conditionArgs = append(conditionArgs, versionValue)
+		}
+	}
+	// 实体的钩子方法可能修改了实体属性，因此需要重新转换操作数据。
+	if called, err := m.callEntityHooks(hookEventBeforeUpdate); err != nil {
+		return nil, err
+	} else if called {
+		updateData = m.getEntitiesData()
+	}
+	handlers := m.getHookHandlers()
+	hookInput := m.newHookInput()
+	hookInput.Condition = conditionWhere + conditionExtra
+	hookInput.Args = conditionArgs
+	if len(handlers) > 0 {
+		refValue := reflect.ValueOf(updateData)
+		if refValue.Kind() == reflect.Ptr {
+			refValue = refValue.Elem()
+		}
+		switch refValue.Kind() {
+		case reflect.Map, reflect.Struct:
+			hookInput.Data = List{ConvertDataForTableRecord(updateData)}
+		}
+		if err = m.callHooks(hookEventBeforeUpdate, handlers, hookInput); err != nil {
+			return nil, err
+		}
+		if len(hookInput.Data) > 0 {
+			updateData = hookInput.Data[0]
+		}
+	}
 	// 自动更新记录更新时间。
 	if !m.unscoped && fieldNameUpdate != "" {
 		var (
-			refValue = reflect.ValueOf(m.data)
-			refKind  = refValue.Kind()
+			refValue    = reflect.ValueOf(updateData)
+			refKind     = refValue.Kind()
+			updateValue = m.getSoftTimeValue(m.getPrimaryTableName(), fieldNameUpdate, m.getSoftTime())
 		)
 		if refKind == reflect.Ptr {
 			refValue = refValue.Elem()
@@ -57,33 +97,97 @@ func (m *Model) Update(dataAndWhere ...interface{}) (result sql.Result, err erro
 		}
 		switch refKind {
 		case reflect.Map, reflect.Struct:
-			dataMap := ConvertDataForTableRecord(m.data)
+			dataMap := ConvertDataForTableRecord(updateData)
 			gutil.MapDelete(dataMap, fieldNameCreate, fieldNameUpdate, fieldNameDelete)
 			if fieldNameUpdate != "" {
-				dataMap[fieldNameUpdate] = gtime.Now().String()
+				dataMap[fieldNameUpdate] = updateValue
 			}
 			updateData = dataMap
 		default:
-			updates := gconv.String(m.data)
+			updates := gconv.String(updateData)
 			if fieldNameUpdate != "" && !gstr.Contains(updates, fieldNameUpdate) {
-				updates += fmt.Sprintf(`,%s='%s'`, fieldNameUpdate, gtime.Now().String())
+				updates += fmt.Sprintf(`,%s='%v'`, fieldNameUpdate, updateValue)
 			}
 			updateData = updates
 		}
 	}
+	// 乐观锁，更新时递增版本号。
+	if versionField != "" {
+		updateData = m.formatVersionData(updateData, versionField)
+	}
 	newData, err := m.filterDataForInsertOrUpdate(updateData)
 	if err != nil {
 		return nil, err
 	}
-	conditionStr := conditionWhere + conditionExtra
+	conditionStr := hookInput.Condition
 	if !gstr.ContainsI(conditionStr, " WHERE ") {
 		return nil, gerror.New("there should be WHERE condition statement for UPDATE operation")
 	}
-	return m.db.DoUpdate(
+	defer func() {
+		if err != nil {
+			return
+		}
+		m.checkAndRemoveCache()
+		if _, err = m.callEntityHooks(hookEventAfterUpdate); err != nil {
+			return
+		}
+		if len(handlers) > 0 {
+			hookInput.SqlResult = result
+			err = m.callHooks(hookEventAfterUpdate, handlers, hookInput)
+		}
+	}()
+	result, err = m.db.DoUpdate(
 		m.getLink(true),
 		m.tables,
 		newData,
 		conditionStr,
-		m.mergeArguments(conditionArgs)...,
+		m.mergeArguments(hookInput.Args)...,
 	)
+	if err == nil && versionValue != nil && !m.db.GetDryRun() {
+		var affected int64
+		if affected, err = result.RowsAffected(); err == nil && affected == 0 {
+			err = ErrOptimisticLock
+		}
+	}
+	return result, err
+}
+
+// Increment 将满足条件的记录的字段<column>的值增加<amount>，即: UPDATE ... SET `column`=`column`+amount WHERE ...
+//
+// 与Update相同，它会自动维护记录的更新时间字段，并且必须设置WHERE条件。
+func (m *Model) Increment(column string, amount float64) (sql.Result, error) {
+	return m.Increments(map[string]float64{column: amount})
+}
+
+// Decrement 将满足条件的记录的字段<column>的值减少<amount>，其他同Increment。
+func (m *Model) Decrement(column string, amount float64) (sql.Result, error) {
+	return m.Increments(map[string]float64{column: -amount})
+}
+
+// Increments 同时增加多个字段的值，参数<columns>的键为字段名称，值为增加的数量，负数表示减少，其他同Increment。
+//
+// Eg:
+//
+// Where("id", 1).Increments(map[string]float64{"views": 1, "stock": -2})
+func (m *Model) Increments(columns map[string]float64) (sql.Result, error) {
+	if len(columns) == 0 {
+		return nil, gerror.New("incrementing table with empty columns")
+	}
+	data := make(Map, len(columns))
+	for column, amount := range columns {
+		data[column] = &Counter{
+			Field: column,
+			Value: amount,
+		}
+	}
+	return m.Data(data).Update()
+}
+
+// Decrements 同时减少多个字段的值，参数<columns>的键为字段名称，值为减少的数量，其他同Increments。
+func (m *Model) Decrements(columns map[string]float64) (sql.Result, error) {
+	negative := make(map[string]float64, len(columns))
+	for column, amount := range columns {
+		negative[column] = -amount
+	}
+	return m.Increments(negative)
 }
@@ -148,8 +148,11 @@ func (m *Model) doMappingAndFilterForInsertOrUpdateDataMap(data Map, allowOmitEm
 // getLink returns the underlying database link object with configured <linkType> attribute.
 // The parameter <master> specifies whether using the master node if master-slave configured.
 func (m *Model) getLink(master bool) Link {
-	if m.tx != nil {
-		return m.tx.tx
+	if tx := m.getTX(); tx != nil {
+		return tx.tx
+	}
+	if m.link != nil {
+		return m.link
 	}
 	linkType := m.linkType
 	if linkType == 0 {
@@ -176,6 +179,15 @@ func (m *Model) getLink(master bool) Link {
 	return nil
 }
 
+// getTX returns the transaction object bound to the model, or the one injected into the
+// context of the model by WithTX/TX.GetCtx. It returns nil if there's no transaction.
+func (m *Model) getTX() *TX {
+	if m.tx != nil {
+		return m.tx
+	}
+	return TXFromCtx(m.db.GetCtx(), m.db.GetGroup())
+}
+
 // getPrimaryKey retrieves and returns the primary key name of the model table.
 // It parses m.tables to retrieve the primary table name, supporting m.tables like:
 // "user", "user u", "user as u, user_detail as ud".
@@ -261,10 +273,11 @@ func (m *Model) formatCondition(limit1 bool, isCountStatement bool) (conditionWh
 			conditionArgs = append(conditionArgs, havingArgs...)
 		}
 	}
-	if m.orderBy != "" {
-		conditionExtra += " ORDER BY " + m.orderBy
-	}
 	if !isCountStatement {
+		// 排序不影响统计结果，部分数据库（如pgsql/mssql）也不支持统计语句或其子查询中的排序。
+		if m.orderBy != "" {
+			conditionExtra += " ORDER BY " + m.orderBy
+		}
 		if m.limit != 0 {
 			if m.start >= 0 {
 				conditionExtra += fmt.Sprintf(" LIMIT %d,%d", m.start, m.limit)
@@ -296,3 +309,15 @@ func (m *Model) mergeArguments(args []interface{}) []interface{} {
 	}
 	return args
 }
+
+// mergeTablesArguments creates and returns new arguments by merging <m.tablesArgs> and given <args>,
+// as the placeholders of sub-queries in tables appear before the ones in the condition.
+func (m *Model) mergeTablesArguments(args []interface{}) []interface{} {
+	if len(m.tablesArgs) > 0 {
+		newArgs := make([]interface{}, len(m.tablesArgs)+len(args))
+		copy(newArgs, m.tablesArgs)
+		copy(newArgs[len(m.tablesArgs):], args)
+		return newArgs
+	}
+	return args
+}
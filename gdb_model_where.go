@@ -0,0 +1,192 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"fmt"
+
+	"github.com/gogf/gf/text/gstr"
+	"github.com/gogf/gf/util/gconv"
+)
+
+const (
+	whereConditionFalse = "0=1" // 恒为假的条件，用于空的IN列表。
+	whereConditionTrue  = "1=1" // 恒为真的条件，用于空的NOT IN列表。
+)
+
+// WhereIn 添加“column IN (...)”条件，<values>为空列表时条件恒为假。
+// <values>也可以是*Model对象，此时将其作为子查询，如: WhereIn("id", db.Model("user").Fields("id"))
+func (m *Model) WhereIn(column string, values interface{}) *Model {
+	return m.doWhereIn(whereHolderWhere, column, values, false)
+}
+
+// WhereNotIn 添加“column NOT IN (...)”条件，<values>为空列表时条件恒为真。
+func (m *Model) WhereNotIn(column string, values interface{}) *Model {
+	return m.doWhereIn(whereHolderWhere, column, values, true)
+}
+
+// WhereBetween 添加“column BETWEEN min AND max”条件。
+func (m *Model) WhereBetween(column string, min, max interface{}) *Model {
+	return m.doWhereOperator(whereHolderWhere, column, "BETWEEN ? AND ?", min, max)
+}
+
+// WhereNotBetween 添加“column NOT BETWEEN min AND max”条件。
+func (m *Model) WhereNotBetween(column string, min, max interface{}) *Model {
+	return m.doWhereOperator(whereHolderWhere, column, "NOT BETWEEN ? AND ?", min, max)
+}
+
+// WhereLike 添加“column LIKE pattern”条件。
+func (m *Model) WhereLike(column string, pattern interface{}) *Model {
+	return m.doWhereOperator(whereHolderWhere, column, "LIKE ?", pattern)
+}
+
+// WhereNotLike 添加“column NOT LIKE pattern”条件。
+func (m *Model) WhereNotLike(column string, pattern interface{}) *Model {
+	return m.doWhereOperator(whereHolderWhere, column, "NOT LIKE ?", pattern)
+}
+
+// WhereNull 添加“column IS NULL”条件。
+func (m *Model) WhereNull(column string) *Model {
+	return m.doWhereOperator(whereHolderWhere, column, "IS NULL")
+}
+
+// WhereNotNull 添加“column IS NOT NULL”条件。
+func (m *Model) WhereNotNull(column string) *Model {
+	return m.doWhereOperator(whereHolderWhere, column, "IS NOT NULL")
+}
+
+// WhereGT 添加“column > value”条件。
+func (m *Model) WhereGT(column string, value interface{}) *Model {
+	return m.doWhereOperator(whereHolderWhere, column, "> ?", value)
+}
+
+// WhereGTE 添加“column >= value”条件。
+func (m *Model) WhereGTE(column string, value interface{}) *Model {
+	return m.doWhereOperator(whereHolderWhere, column, ">= ?", value)
+}
+
+// WhereLT 添加“column < value”条件。
+func (m *Model) WhereLT(column string, value interface{}) *Model {
+	return m.doWhereOperator(whereHolderWhere, column, "< ?", value)
+}
+
+// WhereLTE 添加“column <= value”条件。
+func (m *Model) WhereLTE(column string, value interface{}) *Model {
+	return m.doWhereOperator(whereHolderWhere, column, "<= ?", value)
+}
+
+// WhereOrIn 添加“OR column IN (...)”条件，<values>为空列表时条件恒为假。
+func (m *Model) WhereOrIn(column string, values interface{}) *Model {
+	return m.doWhereIn(whereHolderOr, column, values, false)
+}
+
+// WhereOrNotIn 添加“OR column NOT IN (...)”条件，<values>为空列表时条件恒为真。
+func (m *Model) WhereOrNotIn(column string, values interface{}) *Model {
+	return m.doWhereIn(whereHolderOr, column, values, true)
+}
+
+// WhereOrBetween 添加“OR column BETWEEN min AND max”条件。
+func (m *Model) WhereOrBetween(column string, min, max interface{}) *Model {
+	return m.doWhereOperator(whereHolderOr, column, "BETWEEN ? AND ?", min, max)
+}
+
+// WhereOrNotBetween 添加“OR column NOT BETWEEN min AND max”条件。
+func (m *Model) WhereOrNotBetween(column string, min, max interface{}) *Model {
+	return m.doWhereOperator(whereHolderOr, column, "NOT BETWEEN ? AND ?", min, max)
+}
+
+// WhereOrLike 添加“OR column LIKE pattern”条件。
+func (m *Model) WhereOrLike(column string, pattern interface{}) *Model {
+	return m.doWhereOperator(whereHolderOr, column, "LIKE ?", pattern)
+}
+
+// WhereOrNotLike 添加“OR column NOT LIKE pattern”条件。
+func (m *Model) WhereOrNotLike(column string, pattern interface{}) *Model {
+	return m.doWhereOperator(whereHolderOr, column, "NOT LIKE ?", pattern)
+}
+
+// WhereOrNull 添加“OR column IS NULL”条件。
+func (m *Model) WhereOrNull(column string) *Model {
+	return m.doWhereOperator(whereHolderOr, column, "IS NULL")
+}
+
+// WhereOrNotNull 添加“OR column IS NOT NULL”条件。
+func (m *Model) WhereOrNotNull(column string) *Model {
+	return m.doWhereOperator(whereHolderOr, column, "IS NOT NULL")
+}
+
+// WhereOrGT 添加“OR column > value”条件。
+func (m *Model) WhereOrGT(column string, value interface{}) *Model {
+	return m.doWhereOperator(whereHolderOr, column, "> ?", value)
+}
+
+// WhereOrGTE 添加“OR column >= value”条件。
+func (m *Model) WhereOrGTE(column string, value interface{}) *Model {
+	return m.doWhereOperator(whereHolderOr, column, ">= ?", value)
+}
+
+// WhereOrLT 添加“OR column < value”条件。
+func (m *Model) WhereOrLT(column string, value interface{}) *Model {
+	return m.doWhereOperator(whereHolderOr, column, "< ?", value)
+}
+
+// WhereOrLTE 添加“OR column <= value”条件。
+func (m *Model) WhereOrLTE(column string, value interface{}) *Model {
+	return m.doWhereOperator(whereHolderOr, column, "<= ?", value)
+}
+
+// doWhereIn 添加IN/NOT IN条件。空列表在各数据库中都不是合法的IN语法，因此转换为恒为假/真的条件。
+func (m *Model) doWhereIn(operator int, column string, values interface{}, not bool) *Model {
+	// 子查询。
+	if subModel, ok := values.(*Model); ok {
+		if not {
+			return m.doWhereOperator(operator, column, "NOT IN(?)", subModel)
+		}
+		return m.doWhereOperator(operator, column, "IN(?)", subModel)
+	}
+	array := gconv.Interfaces(values)
+	if len(array) == 0 {
+		if not {
+			return m.doWhere(operator, whereConditionTrue)
+		}
+		return m.doWhere(operator, whereConditionFalse)
+	}
+	if not {
+		return m.doWhereOperator(operator, column, "NOT IN(?)", array)
+	}
+	return m.doWhereOperator(operator, column, "IN(?)", array)
+}
+
+// doWhereOperator 添加“column operation”条件，字段名总是使用安全字符包裹，值总是作为参数绑定。
+func (m *Model) doWhereOperator(operator int, column string, operation string, args ...interface{}) *Model {
+	return m.doWhere(operator, fmt.Sprintf(`%s %s`, m.quoteColumn(column), operation), args...)
+}
+
+// doWhere 根据<operator>添加AND或者OR条件。
+func (m *Model) doWhere(operator int, where interface{}, args ...interface{}) *Model {
+	if operator == whereHolderOr {
+		return m.Or(where, args...)
+	}
+	return m.Where(where, args...)
+}
+
+// quoteColumn 使用安全字符包裹字段名<column>，支持“table.column”格式。
+// 与QuoteWord不同的是，字段名中包含空格或者运算符等字符时也会被包裹，已经包裹的部分不会重复处理。
+func (m *Model) quoteColumn(column string) string {
+	charLeft, charRight := m.db.GetChars()
+	if charLeft == "" && charRight == "" {
+		return column
+	}
+	array := gstr.SplitAndTrim(column, ".")
+	for k, v := range array {
+		if gstr.HasPrefix(v, charLeft) && gstr.HasSuffix(v, charRight) {
+			continue
+		}
+		array[k] = charLeft + v + charRight
+	}
+	return gstr.Join(array, ".")
+}
@@ -0,0 +1,374 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+
+	"github.com/gogf/gf/errors/gerror"
+	"github.com/gogf/gf/internal/utils"
+	"github.com/gogf/gf/text/gstr"
+	"github.com/gogf/gf/util/gconv"
+)
+
+// apiTableName 是实体结构体指定其对应数据表名称的接口支持。
+type apiTableName interface {
+	TableName() string
+}
+
+// withRelation 是通过结构体标签声明的关联关系，如: `orm:"with:uid=id"`。
+//
+// 多对多关联通过中间表声明，如: `orm:"with:id=role_id, pivot:user_role, pivot_key:uid=id"`，
+// 表示关联表的"id"字段对应中间表的"role_id"字段，中间表的"uid"字段对应当前表的"id"字段。
+type withRelation struct {
+	relatedKey      string // 关联表的字段名，如: uid。
+	localKey        string // 当前表的字段名，如: id。
+	table           string // 关联表名，可选，如: `orm:"with:uid=id, table:user_detail"`。
+	pivotTable      string // 多对多关联的中间表名，如: user_role。
+	pivotLocalKey   string // 中间表中对应当前表的字段名，如: uid。
+	pivotForeignKey string // 中间表中对应关联表的字段名，如: role_id。
+}
+
+// With 开启指定关联属性的预加载，在调用Struct/Structs/Scan时自动查询关联数据并绑定到对应属性。
+//
+// 参数<objects>为关联属性的对象(或其指针)，根据类型匹配实体中通过`orm:"with:uid=id"`标签声明的关联属性，
+// 其中"uid"为关联表的字段名，"id"为当前表的字段名。关联表名默认为关联结构体名称的蛇形命名，
+// 也可以通过"table"标签或者实现TableName方法指定。
+//
+// Eg:
+//
+//	type User struct {
+//	    Id     int           `orm:"id"`
+//	    Detail *UserDetail   `orm:"with:uid=id"`
+//	    Scores []*UserScores `orm:"with:uid=id"`
+//	}
+//
+// db.Model("user").With(UserDetail{}, UserScores{}).Scan(&users)
+func (m *Model) With(objects ...interface{}) *Model {
+	model := m.getModel()
+	model.withArray = append(model.withArray, objects...)
+	return model
+}
+
+// WithAll 开启所有关联属性的预加载，包括关联对象中的关联属性。
+func (m *Model) WithAll() *Model {
+	model := m.getModel()
+	model.withAll = true
+	return model
+}
+
+// doWithScanStruct 为Struct查询的结果加载关联数据，<pointer>的类型应为*struct/**struct。
+func (m *Model) doWithScanStruct(pointer interface{}) error {
+	if !m.withAll && len(m.withArray) == 0 {
+		return nil
+	}
+	reflectValue := reflect.ValueOf(pointer)
+	for reflectValue.Kind() == reflect.Ptr {
+		reflectValue = reflectValue.Elem()
+	}
+	if reflectValue.Kind() != reflect.Struct {
+		return nil
+	}
+	return m.doWithScanValues([]reflect.Value{reflectValue}, reflectValue.Type())
+}
+
+// doWithScanStructs 为Structs查询的结果加载关联数据，<pointer>的类型应为*[]struct/*[]*struct。
+func (m *Model) doWithScanStructs(pointer interface{}) error {
+	if !m.withAll && len(m.withArray) == 0 {
+		return nil
+	}
+	reflectValue := reflect.ValueOf(pointer)
+	for reflectValue.Kind() == reflect.Ptr {
+		reflectValue = reflectValue.Elem()
+	}
+	if reflectValue.Kind() != reflect.Slice && reflectValue.Kind() != reflect.Array {
+		return nil
+	}
+	items := make([]reflect.Value, 0, reflectValue.Len())
+	for i := 0; i < reflectValue.Len(); i++ {
+		item := reflectValue.Index(i)
+		for item.Kind() == reflect.Ptr && !item.IsNil() {
+			item = item.Elem()
+		}
+		if item.Kind() == reflect.Struct {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return m.doWithScanValues(items, items[0].Type())
+}
+
+// doWithScanValues 为结构体对象<items>批量查询并绑定所有开启预加载的关联属性，每个关联属性只执行一次"IN"查询。
+//
+// 类型已在当前预加载路径上的关联属性将被跳过，以避免循环关联(如User->Detail->User)无限查询。
+func (m *Model) doWithScanValues(items []reflect.Value, structType reflect.Type) error {
+	withPath := make([]reflect.Type, 0, len(m.withPath)+1)
+	withPath = append(withPath, m.withPath...)
+	withPath = append(withPath, structType)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		relation, ok := parseWithRelation(field.Tag.Get(OrmTagForStruct))
+		if !ok {
+			continue
+		}
+		var (
+			isMany      = false
+			isPtr       = false
+			relatedType = field.Type
+		)
+		if relatedType.Kind() == reflect.Slice || relatedType.Kind() == reflect.Array {
+			isMany = true
+			relatedType = relatedType.Elem()
+		}
+		if relatedType.Kind() == reflect.Ptr {
+			isPtr = true
+			relatedType = relatedType.Elem()
+		}
+		if relatedType.Kind() != reflect.Struct {
+			return gerror.Newf(`invalid with attribute "%s": it should be type of struct/*struct/[]struct/[]*struct`, field.Name)
+		}
+		if !m.isWithEnabled(relatedType) || isTypeInWithPath(withPath, relatedType) {
+			continue
+		}
+		localAttrName, ok := getStructAttrNameByColumn(structType, relation.localKey)
+		if !ok {
+			return gerror.Newf(`invalid with attribute "%s": cannot find attribute for field "%s"`, field.Name, relation.localKey)
+		}
+		relatedAttrName, ok := getStructAttrNameByColumn(relatedType, relation.relatedKey)
+		if !ok {
+			return gerror.Newf(`invalid with attribute "%s": cannot find attribute for field "%s"`, field.Name, relation.relatedKey)
+		}
+		// 收集当前结果中的关联键值，去重后执行一次"IN"查询。
+		var (
+			keyValues = make([]interface{}, 0, len(items))
+			keySet    = make(map[string]struct{}, len(items))
+		)
+		for _, item := range items {
+			v := item.FieldByName(localAttrName).Interface()
+			k := gconv.String(v)
+			if _, ok := keySet[k]; !ok {
+				keySet[k] = struct{}{}
+				keyValues = append(keyValues, v)
+			}
+		}
+		relatedGroups, err := m.getWithRelatedGroups(relation, relatedType, relatedAttrName, keyValues, withPath)
+		if err != nil {
+			return err
+		}
+		// 按照关联键值将关联数据绑定到对应的属性上。
+		for _, item := range items {
+			var (
+				attrValue = item.FieldByName(field.Name)
+				matched   = relatedGroups[gconv.String(item.FieldByName(localAttrName).Interface())]
+			)
+			if isMany {
+				slice := reflect.MakeSlice(reflect.SliceOf(field.Type.Elem()), 0, len(matched))
+				for _, v := range matched {
+					if isPtr {
+						slice = reflect.Append(slice, v)
+					} else {
+						slice = reflect.Append(slice, v.Elem())
+					}
+				}
+				attrValue.Set(slice)
+				continue
+			}
+			if len(matched) == 0 {
+				continue
+			}
+			if isPtr {
+				attrValue.Set(matched[0])
+			} else {
+				attrValue.Set(matched[0].Elem())
+			}
+		}
+	}
+	return nil
+}
+
+// getWithRelatedGroups 根据当前表的关联键值<keyValues>查询关联数据，返回以当前表关联键值为键名的关联对象(指针)分组。
+func (m *Model) getWithRelatedGroups(
+	relation withRelation, relatedType reflect.Type, relatedAttrName string, keyValues []interface{}, withPath []reflect.Type,
+) (map[string][]reflect.Value, error) {
+	var (
+		table         = relation.table
+		relatedGroups = make(map[string][]reflect.Value)
+	)
+	if table == "" {
+		table = getTableNameByStructType(relatedType)
+	}
+	// 多对多关联，先从中间表查询关联表的键值。
+	var (
+		pivotResult  Result
+		relatedWhere = keyValues
+	)
+	if relation.pivotTable != "" {
+		var err error
+		pivotResult, err = m.getWithModel(relation.pivotTable, withPath).
+			Fields(relation.pivotLocalKey+","+relation.pivotForeignKey).
+			Where(relation.pivotLocalKey, keyValues).
+			All()
+		if err != nil {
+			return nil, err
+		}
+		if len(pivotResult) == 0 {
+			return relatedGroups, nil
+		}
+		relatedWhere = make([]interface{}, 0, len(pivotResult))
+		for _, record := range pivotResult {
+			relatedWhere = append(relatedWhere, record[relation.pivotForeignKey].Val())
+		}
+	}
+	relatedListPointer := reflect.New(reflect.SliceOf(reflect.PtrTo(relatedType)))
+	err := m.getWithModel(table, withPath).
+		Where(relation.relatedKey, relatedWhere).
+		Structs(relatedListPointer.Interface())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	relatedList := relatedListPointer.Elem()
+	for i := 0; i < relatedList.Len(); i++ {
+		relatedItem := relatedList.Index(i)
+		k := gconv.String(relatedItem.Elem().FieldByName(relatedAttrName).Interface())
+		relatedGroups[k] = append(relatedGroups[k], relatedItem)
+	}
+	if relation.pivotTable == "" {
+		return relatedGroups, nil
+	}
+	// 按照中间表的对应关系重新分组，键名为当前表的关联键值。
+	pivotGroups := make(map[string][]reflect.Value)
+	for _, record := range pivotResult {
+		k := record[relation.pivotLocalKey].String()
+		pivotGroups[k] = append(pivotGroups[k], relatedGroups[record[relation.pivotForeignKey].String()]...)
+	}
+	return pivotGroups, nil
+}
+
+// isWithEnabled 检查类型为<relatedType>的关联属性是否开启了预加载。
+func (m *Model) isWithEnabled(relatedType reflect.Type) bool {
+	if m.withAll {
+		return true
+	}
+	for _, object := range m.withArray {
+		objectType := reflect.TypeOf(object)
+		for objectType != nil && (objectType.Kind() == reflect.Ptr || objectType.Kind() == reflect.Slice) {
+			objectType = objectType.Elem()
+		}
+		if objectType == relatedType {
+			return true
+		}
+	}
+	return false
+}
+
+// getWithModel 创建并返回用于查询关联数据的模型，它与当前模型使用相同的事务和预加载设置，
+// 参数<withPath>为当前的预加载路径。
+func (m *Model) getWithModel(table string, withPath []reflect.Type) *Model {
+	var model *Model
+	if m.tx != nil {
+		model = m.tx.Model(table)
+	} else {
+		model = m.db.Model(table)
+	}
+	model.withAll = m.withAll
+	model.withArray = m.withArray
+	model.withPath = withPath
+	return model
+}
+
+// isTypeInWithPath 检查结构体类型<structType>是否已在预加载路径<withPath>上。
+func isTypeInWithPath(withPath []reflect.Type, structType reflect.Type) bool {
+	for _, v := range withPath {
+		if v == structType {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWithRelation 解析结构体属性的orm标签中的关联关系，如:
+// "with:uid=id"、"with:uid=id, table:user_detail"、"with:id=role_id, pivot:user_role, pivot_key:uid=id"。
+func parseWithRelation(tag string) (relation withRelation, ok bool) {
+	var pivotLocalKey string
+	for _, item := range gstr.SplitAndTrim(tag, ",") {
+		array := gstr.SplitAndTrim(item, ":")
+		if len(array) != 2 {
+			continue
+		}
+		switch array[0] {
+		case OrmTagForWith:
+			relation.relatedKey, relation.localKey = parseWithRelationKeys(array[1])
+			ok = true
+		case OrmTagForTable:
+			relation.table = array[1]
+		case OrmTagForPivot:
+			relation.pivotTable = array[1]
+		case OrmTagForPivotKey:
+			relation.pivotLocalKey, pivotLocalKey = parseWithRelationKeys(array[1])
+		}
+	}
+	if ok && relation.pivotTable != "" {
+		// 多对多关联中，"with"标签的右侧为中间表的字段名，"pivot_key"标签的右侧为当前表的字段名。
+		relation.pivotForeignKey, relation.localKey = relation.localKey, pivotLocalKey
+		if relation.pivotLocalKey == "" {
+			ok = false
+		}
+	}
+	return
+}
+
+// parseWithRelationKeys 解析"uid=id"格式的关联字段，如果没有"="则两侧字段名相同。
+func parseWithRelationKeys(s string) (left, right string) {
+	keys := gstr.SplitAndTrim(s, "=")
+	left, right = keys[0], keys[0]
+	if len(keys) > 1 {
+		right = keys[1]
+	}
+	return
+}
+
+// getStructAttrNameByColumn 根据数据表字段名<column>查找结构体<structType>中对应的属性名，
+// 优先匹配orm标签，其次忽略大小写及'-'/'_'/'.'/' '字符匹配属性名。
+func getStructAttrNameByColumn(structType reflect.Type, column string) (string, bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				if name, ok := getStructAttrNameByColumn(fieldType, column); ok {
+					return name, true
+				}
+			}
+			continue
+		}
+		if tag := field.Tag.Get(OrmTagForStruct); tag != "" && strings.TrimSpace(strings.Split(tag, ",")[0]) == column {
+			return field.Name, true
+		}
+	}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.Anonymous && utils.EqualFoldWithoutChars(field.Name, column) {
+			return field.Name, true
+		}
+	}
+	return "", false
+}
+
+// getTableNameByStructType 返回结构体类型对应的数据表名，如果结构体实现了TableName方法则使用其返回值，否则使用结构体名称的蛇形命名。
+func getTableNameByStructType(structType reflect.Type) string {
+	if v, ok := reflect.New(structType).Interface().(apiTableName); ok {
+		return v.TableName()
+	}
+	return gstr.CaseSnake(structType.Name())
+}
@@ -6,12 +6,17 @@
 
 package gdb
 
-import "database/sql"
+import (
+	"database/sql"
+	"strings"
+)
 
 // SqlResult 是sql操作的执行结果。它还支持rowsAffected的批处理操作结果。
 type SqlResult struct {
-	result   sql.Result
-	affected int64
+	result      sql.Result
+	affected    int64
+	returning   Result // 写入语句通过返回子句返回的记录。
+	insertIdKey string // 用于获取最后写入id的返回字段，不为空时LastInsertId从返回的记录中获取。
 }
 
 // MustGetAffected 返回受影响的行数，如果发生任何错误，它将崩溃。
@@ -44,9 +49,33 @@ func (r *SqlResult) RowsAffected() (int64, error) {
 }
 
 // see sql.Result.LastInsertId
+//
+// 对于不支持LastInsertId的驱动(如pgsql/oracle)，通过Model写入时会自动返回表的主键字段，
+// 并使用最后一条返回记录的主键值作为最后写入的id。mssql的OUTPUT子句不能用于启用了触发器的表，
+// 因此只在调用Model.Returning时返回主键字段。
 func (r *SqlResult) LastInsertId() (int64, error) {
+	if r.insertIdKey != "" {
+		if len(r.returning) == 0 {
+			return 0, nil
+		}
+		for k, v := range r.returning[len(r.returning)-1] {
+			if strings.EqualFold(k, r.insertIdKey) {
+				return v.Int64(), nil
+			}
+		}
+	}
 	if r.result == nil {
 		return 0, nil
 	}
 	return r.result.LastInsertId()
 }
+
+// Returning 返回写入语句通过Model.Returning指定的返回子句所返回的记录。
+//
+// Eg:
+//
+// r, err := db.Model("user").Returning("id,created_at").Data(data).Insert()
+// records := r.(*gdb.SqlResult).Returning()
+func (r *SqlResult) Returning() Result {
+	return r.returning
+}
@@ -7,28 +7,240 @@
 package gdb
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
+	"time"
 
+	"github.com/gogf/gf/errors/gerror"
 	"github.com/gogf/gf/text/gregex"
+	"github.com/gogf/gf/util/grand"
 )
 
 // TX 是事务管理的结构体。
 type TX struct {
-	db     DB
-	tx     *sql.Tx
-	master *sql.DB
+	db               DB
+	tx               *sql.Tx
+	master           *sql.DB            // 开启事务的连接池对象，只读事务可能是从节点。
+	ctx              context.Context    // 注入了当前事务对象的上下文。
+	cancelFunc       context.CancelFunc // 事务超时上下文的取消函数，在事务结束时调用。
+	transactionCount int                // 嵌套事务的层数，用于生成保存点名称。
+	onCommit         []func()           // 事务提交成功后执行的回调函数。
+	onRollback       []func()           // 事务回滚后执行的回调函数。
+}
+
+// TxOptions 是开启事务时的选项。
+type TxOptions struct {
+	Isolation sql.IsolationLevel // 事务隔离级别，默认使用数据库的默认隔离级别。
+	ReadOnly  bool               // 是否为只读事务，只读事务将在从节点上执行(如果配置了主从)。
+	Timeout   time.Duration      // 事务的最大执行时间，为0时使用配置项TranTimeout。
+	Retry     TxRetryPolicy      // 事务在死锁或序列化失败时的自动重试策略，为空时使用配置项TranRetryAttempts/TranRetryInterval。
+}
+
+// TxRetryPolicy 是事务闭包在死锁、序列化失败等可重试错误时自动重新执行的策略。
+type TxRetryPolicy struct {
+	MaxAttempts int                  // 最大执行次数(包含首次执行)，小于等于1时不重试。
+	Interval    time.Duration        // 首次重试前的等待时间，之后每次重试翻倍，实际等待时间会加入随机抖动。
+	MaxInterval time.Duration        // 重试等待时间的上限，为0时不限制。
+	Retryable   func(err error) bool // 自定义可重试错误的判断函数，参数为底层驱动的原始错误，为nil时使用驱动的IsRetryableError。
+}
+
+const (
+	// defaultTxRetryInterval 是未配置重试等待时间时的默认值。
+	defaultTxRetryInterval = 50 * time.Millisecond
+)
+
+// backoff 返回第<attempt>次执行失败后、下一次重试前的等待时间。
+//
+// 等待时间按指数增长，并在[d/2, d]区间内随机抖动，以避免多个冲突的事务同时重试。
+func (p TxRetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Interval
+	if d <= 0 {
+		d = defaultTxRetryInterval
+	}
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxInterval > 0 && d >= p.MaxInterval {
+			d = p.MaxInterval
+			break
+		}
+	}
+	if half := int(d / 2); half > 0 {
+		return time.Duration(half + grand.N(0, half))
+	}
+	return d
+}
+
+const (
+	// transactionSavePointPrefix 是嵌套事务自动生成的保存点名称前缀。
+	transactionSavePointPrefix = "gf_save_point_"
+	// transactionKeyForContext 是事务对象保存在上下文中的键名前缀，后面拼接配置组名称。
+	transactionKeyForContext = "TransactionObjectForGroup_"
+)
+
+// WithTX 将事务对象<tx>注入到上下文<ctx>中并返回新的上下文。
+//
+// 使用该上下文的Model.Ctx/DB.Ctx操作将自动在该事务上执行。
+func WithTX(ctx context.Context, tx *TX) context.Context {
+	if tx == nil {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, transactionKeyForContext+tx.db.GetGroup(), tx)
+}
+
+// TXFromCtx 从上下文<ctx>中检索并返回配置组<group>的事务对象，如果不存在则返回nil。
+func TXFromCtx(ctx context.Context, group string) *TX {
+	if ctx == nil {
+		return nil
+	}
+	if tx, ok := ctx.Value(transactionKeyForContext + group).(*TX); ok {
+		return tx
+	}
+	return nil
+}
+
+// GetCtx 返回注入了当前事务对象的上下文。
+//
+// 将该上下文传递给Model.Ctx/DB.Ctx后，相关操作将自动加入当前事务，而不需要显式传递事务对象。
+func (tx *TX) GetCtx() context.Context {
+	if tx.ctx == nil {
+		tx.ctx = WithTX(context.Background(), tx)
+	}
+	return tx.ctx
 }
 
 // Commit 提交事务
+//
+// 提交成功后按注册顺序执行OnCommit注册的回调函数，提交失败则执行OnRollback注册的回调函数。
 func (tx *TX) Commit() error {
-	return tx.tx.Commit()
+	err := tx.tx.Commit()
+	if tx.cancelFunc != nil {
+		tx.cancelFunc()
+	}
+	if err == nil {
+		tx.runCallbacks(tx.onCommit)
+	} else {
+		tx.runCallbacks(tx.onRollback)
+	}
+	tx.onCommit, tx.onRollback = nil, nil
+	return err
 }
 
 // Rollback 中止事务(事务回滚)
+//
+// 回滚后按注册顺序执行OnRollback注册的回调函数。
 func (tx *TX) Rollback() error {
-	return tx.tx.Rollback()
+	err := tx.tx.Rollback()
+	if tx.cancelFunc != nil {
+		tx.cancelFunc()
+	}
+	tx.runCallbacks(tx.onRollback)
+	tx.onCommit, tx.onRollback = nil, nil
+	return err
+}
+
+// OnCommit 注册一个在事务提交成功后执行的回调函数，常用于发布事件、清理缓存等只应在数据真正落库后执行的操作。
+//
+// 如果回调函数是在嵌套事务(TX.Transaction)中注册的，而该嵌套事务回滚到了保存点，那么该回调函数将被丢弃。
+func (tx *TX) OnCommit(f func()) {
+	tx.onCommit = append(tx.onCommit, f)
+}
+
+// OnRollback 注册一个在事务回滚后执行的回调函数。
+//
+// 如果回调函数是在嵌套事务(TX.Transaction)中注册的，那么该嵌套事务回滚到保存点时它也会被执行。
+func (tx *TX) OnRollback(f func()) {
+	tx.onRollback = append(tx.onRollback, f)
+}
+
+// runCallbacks 按顺序执行回调函数，单个回调函数的panic会被捕获并记录日志，不影响其他回调函数的执行。
+func (tx *TX) runCallbacks(callbacks []func()) {
+	for _, f := range callbacks {
+		func() {
+			defer func() {
+				if e := recover(); e != nil {
+					tx.db.GetLogger().Ctx(tx.db.GetCtx()).Errorf(`transaction callback panic: %v`, e)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+// SavePoint 在当前事务中创建名为<name>的保存点。
+func (tx *TX) SavePoint(name string) error {
+	_, err := tx.Exec(tx.db.GetSavePointSql(savePointOperationCreate, tx.db.QuoteWord(name)))
+	return err
+}
+
+// RollbackTo 将当前事务回滚到名为<name>的保存点，保存点之前的操作不受影响。
+func (tx *TX) RollbackTo(name string) error {
+	_, err := tx.Exec(tx.db.GetSavePointSql(savePointOperationRollback, tx.db.QuoteWord(name)))
+	return err
+}
+
+// ReleaseSavePoint 释放当前事务中名为<name>的保存点。
+//
+// 注意: 部分数据库(如mssql、oracle)不支持释放保存点，此时该方法不执行任何操作。
+func (tx *TX) ReleaseSavePoint(name string) error {
+	s := tx.db.GetSavePointSql(savePointOperationRelease, tx.db.QuoteWord(name))
+	if s == "" {
+		return nil
+	}
+	_, err := tx.Exec(s)
+	return err
+}
+
+// Transaction 使用函数<f>包装嵌套事务逻辑，嵌套事务通过保存点(SAVEPOINT)实现。
+//
+// 如果函数<f>返回非nil错误或者发生panic，它只回滚到本次嵌套事务开始时的保存点并返回错误，外层事务不受影响；
+// 如果函数<f>返回nil，则释放保存点并返回nil，其修改将随外层事务一起提交或回滚。
+//
+// 注意: 您不应该在函数<f>中提交或回滚事务，因为它是由该函数自动处理的。
+func (tx *TX) Transaction(f func(tx *TX) error) (err error) {
+	tx.transactionCount++
+	var (
+		savePointName   = fmt.Sprintf(`%s%d`, transactionSavePointPrefix, tx.transactionCount)
+		onCommitCount   = len(tx.onCommit)
+		onRollbackCount = len(tx.onRollback)
+	)
+	if err = tx.SavePoint(savePointName); err != nil {
+		tx.transactionCount--
+		return err
+	}
+	defer func() {
+		if err == nil {
+			if e := recover(); e != nil {
+				if v, ok := e.(error); ok {
+					err = v
+				} else {
+					err = fmt.Errorf("%v", e)
+				}
+			}
+		}
+		if err != nil {
+			// It keeps the original error of <f> or the recovered panic, and wraps the rollback error with it.
+			if e := tx.RollbackTo(savePointName); e != nil {
+				err = gerror.Wrapf(err, "rollback to savepoint failed: %v", e)
+			}
+			// The callbacks registered in this nested transaction are discarded,
+			// and its rollback callbacks are called as its changes are rolled back.
+			tx.runCallbacks(tx.onRollback[onRollbackCount:])
+			tx.onCommit = tx.onCommit[:onCommitCount]
+			tx.onRollback = tx.onRollback[:onRollbackCount]
+		} else {
+			if e := tx.ReleaseSavePoint(savePointName); e != nil {
+				err = e
+			}
+		}
+		tx.transactionCount--
+	}()
+	err = f(tx)
+	return
 }
 
 // Query 对事务执行查询操作
@@ -8,8 +8,12 @@ package gdb_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/gogf/gf/errors/gerror"
+	"github.com/gogf/gf/frame/g"
+
 	"github.com/gogf/gf/database/gdb"
 	"github.com/gogf/gf/test/gtest"
 )
@@ -62,3 +66,38 @@ func Test_Ctx_Model(t *testing.T) {
 		db.Model(table).All()
 	})
 }
+
+func Test_Ctx_Transaction(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	updateNickname := func(ctx context.Context, id int, nickname string) error {
+		_, err := db.Model(table).Ctx(ctx).Data("nickname", nickname).Where("id", id).Update()
+		return err
+	}
+	gtest.C(t, func(t *gtest.T) {
+		err := db.Transaction(func(tx *gdb.TX) error {
+			ctx := tx.GetCtx()
+			if err := updateNickname(ctx, 1, "NAME_1"); err != nil {
+				return err
+			}
+			if _, err := db.Ctx(ctx).Exec(fmt.Sprintf("UPDATE %s SET nickname='NAME_2' WHERE id=2", table)); err != nil {
+				return err
+			}
+			// Nested transaction through the context.
+			err := db.Ctx(ctx).Transaction(func(tx *gdb.TX) error {
+				if err := updateNickname(tx.GetCtx(), 3, "NAME_3"); err != nil {
+					return err
+				}
+				return gerror.New("error")
+			})
+			t.AssertNE(err, nil)
+			return gerror.New("error")
+		})
+		t.AssertNE(err, nil)
+
+		array, err := db.Model(table).Fields("nickname").Where("id", g.Slice{1, 2, 3}).Order("id asc").Array()
+		t.Assert(err, nil)
+		t.Assert(array, g.Slice{"name_1", "name_2", "name_3"})
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gogf/gf/container/gvar"
+	"github.com/gogf/gf/database/gdb"
+	"github.com/gogf/gf/errors/gerror"
+	"github.com/gogf/gf/frame/g"
+	"github.com/gogf/gf/test/gtest"
+)
+
+type hookUser struct {
+	Id       int    `orm:"id"`
+	Passport string `orm:"passport"`
+	Password string `orm:"password"`
+	Nickname string `orm:"nickname"`
+}
+
+func (u *hookUser) BeforeInsert(ctx context.Context) error {
+	u.Nickname = "before_insert_" + u.Nickname
+	return nil
+}
+
+func (u *hookUser) BeforeUpdate(ctx context.Context) error {
+	if u.Nickname == "" {
+		return gerror.New("empty nickname")
+	}
+	return nil
+}
+
+func Test_Model_Hook_Insert_Update(t *testing.T) {
+	table := createTable()
+	defer dropTable(table)
+	defer gdb.UnregisterHook(table)
+
+	var (
+		events          = make([]string, 0)
+		updatedAffected int64
+	)
+	gdb.RegisterHook(table, gdb.HookHandler{
+		BeforeInsert: func(in *gdb.HookInput) error {
+			events = append(events, "BeforeInsert")
+			for _, item := range in.Data {
+				item["password"] = "hook_password"
+			}
+			return nil
+		},
+		AfterInsert: func(in *gdb.HookInput) error {
+			events = append(events, "AfterInsert")
+			return nil
+		},
+		BeforeUpdate: func(in *gdb.HookInput) error {
+			events = append(events, "BeforeUpdate")
+			in.Data[0]["passport"] = "hook_passport"
+			return nil
+		},
+		AfterUpdate: func(in *gdb.HookInput) error {
+			events = append(events, "AfterUpdate")
+			updatedAffected, _ = in.SqlResult.RowsAffected()
+			return nil
+		},
+	})
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Model(table).Data(&hookUser{
+			Id:       1,
+			Passport: "user_1",
+			Password: "pass_1",
+			Nickname: "name_1",
+		}).Insert()
+		t.Assert(err, nil)
+
+		one, err := db.Model(table).FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one["password"].String(), "hook_password")
+		t.Assert(one["nickname"].String(), "before_insert_name_1")
+
+		_, err = db.Model(table).Data(g.Map{"nickname": "name_100"}).Where("id", 1).Update()
+		t.Assert(err, nil)
+		t.Assert(updatedAffected, 1)
+
+		one, err = db.Model(table).FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one["passport"].String(), "hook_passport")
+		t.Assert(one["nickname"].String(), "name_100")
+
+		_, err = db.Model(table).Data(&hookUser{Id: 1}).Where("id", 1).Update()
+		t.AssertNE(err, nil)
+
+		t.Assert(events, g.SliceStr{"BeforeInsert", "AfterInsert", "BeforeUpdate", "AfterUpdate"})
+	})
+}
+
+func Test_Model_Hook_Delete_Select(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+	defer gdb.UnregisterHook(table)
+
+	gdb.RegisterHook(table, gdb.HookHandler{
+		BeforeDelete: func(in *gdb.HookInput) error {
+			if len(in.Args) > 0 && in.Args[0] == 1 {
+				return gerror.New("record 1 cannot be deleted")
+			}
+			return nil
+		},
+		AfterSelect: func(in *gdb.HookInput) error {
+			for _, record := range in.Result {
+				record["password"] = gvar.New("***")
+			}
+			return nil
+		},
+	})
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Model(table).Where("id", 1).Delete()
+		t.AssertNE(err, nil)
+
+		_, err = db.Model(table).Where("id", 2).Delete()
+		t.Assert(err, nil)
+
+		all, err := db.Model(table).Order("id asc").All()
+		t.Assert(err, nil)
+		t.Assert(len(all), SIZE-1)
+		t.Assert(all[0]["id"].Int(), 1)
+		t.Assert(all[0]["password"].String(), "***")
+	})
+	gtest.C(t, func(t *gtest.T) {
+		gdb.UnregisterHook(table)
+		_, err := db.Model(table).Where("id", 1).Delete()
+		t.Assert(err, nil)
+
+		one, err := db.Model(table).Unscoped().FindOne(3)
+		t.Assert(err, nil)
+		t.AssertNE(one["password"].String(), "***")
+	})
+}
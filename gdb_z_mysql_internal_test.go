@@ -7,12 +7,16 @@
 package gdb
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/go-sql-driver/mysql"
 	"github.com/gogf/gf/container/gvar"
+	"github.com/gogf/gf/errors/gerror"
 	"github.com/gogf/gf/os/gcmd"
 	"github.com/gogf/gf/os/gtime"
 	"github.com/gogf/gf/test/gtest"
+	"github.com/gogf/gf/text/gstr"
 	"testing"
 )
 
@@ -290,6 +294,64 @@ CREATE TABLE %s (
 			"`t1`.`delete_at` IS NULL AND `t2`.`deleteat` IS NULL AND `t3`.`deleteat` IS NULL",
 		)
 	})
+	gtest.C(t, func(t *gtest.T) {
+		model := db.Table(table1+" as t1").LeftJoin(table2+" as t2", "t2.id2=t1.id1").OnlyTrashed()
+		t.Assert(model.getConditionForSoftDeleting(), "`t1`.`delete_at` IS NOT NULL AND `t2`.`deleteat` IS NULL")
+	})
+}
+
+func Test_getSoftDeleteStrategyNameByFieldType(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(getSoftDeleteStrategyNameByFieldType("datetime"), SoftDeleteDatetime)
+		t.Assert(getSoftDeleteStrategyNameByFieldType("timestamp"), SoftDeleteDatetime)
+		t.Assert(getSoftDeleteStrategyNameByFieldType("varchar(20)"), SoftDeleteDatetime)
+		t.Assert(getSoftDeleteStrategyNameByFieldType("tinyint(1)"), SoftDeleteFlag)
+		t.Assert(getSoftDeleteStrategyNameByFieldType("BOOLEAN"), SoftDeleteBool)
+		t.Assert(getSoftDeleteStrategyNameByFieldType("bool"), SoftDeleteBool)
+		t.Assert(getSoftDeleteStrategyNameByFieldType("int(11) unsigned"), SoftDeleteUnix)
+		t.Assert(getSoftDeleteStrategyNameByFieldType("integer"), SoftDeleteUnix)
+		t.Assert(getSoftDeleteStrategyNameByFieldType("bigint(20)"), SoftDeleteUnixMilli)
+		t.Assert(getSoftDeleteStrategyNameByFieldType("int8"), SoftDeleteUnixMilli)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		strategy := softDeleteStrategyMap[SoftDeleteFlag]
+		t.Assert(strategy.DeletedValue(gtime.Now()), 1)
+		t.Assert(strategy.LiveValue(), 0)
+		t.Assert(strategy.Condition("`is_deleted`", false), "`is_deleted`=0")
+		t.Assert(strategy.Condition("`is_deleted`", true), "`is_deleted`<>0")
+		t.AssertNE(RegisterSoftDeleteStrategy("nil", nil), nil)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		strategy := softDeleteStrategyMap[SoftDeleteBool]
+		t.Assert(strategy.DeletedValue(gtime.Now()), true)
+		t.Assert(strategy.LiveValue(), false)
+		t.Assert(strategy.Condition(`"deleted"`, false), `"deleted" IS NOT TRUE`)
+		t.Assert(strategy.Condition(`"deleted"`, true), `"deleted" IS TRUE`)
+	})
+}
+
+func Test_Model_getSoftDeleteTimeStrategy(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		model := &Model{}
+		for _, name := range []string{SoftDeleteDatetime, SoftDeleteUnix, SoftDeleteUnixMilli} {
+			strategy := model.getSoftDeleteTimeStrategy(softDeleteStrategyMap[name], "user", "deleted_at")
+			_, ok := strategy.(softDeleteTime)
+			t.Assert(ok, true)
+			t.Assert(strategy.LiveValue(), softDeleteStrategyMap[name].LiveValue())
+			t.Assert(strategy.Condition("`deleted_at`", true), softDeleteStrategyMap[name].Condition("`deleted_at`", true))
+		}
+		custom := softDeleteBool{}
+		t.Assert(model.getSoftDeleteTimeStrategy(custom, "user", "deleted_at"), custom)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverOracle{Core: core}
+		v, ok := core.DB.(interface{ isDateWithTime() bool })
+		t.Assert(ok, true)
+		t.Assert(v.isDateWithTime(), true)
+		_, ok = DB(&DriverMysql{Core: core}).(interface{ isDateWithTime() bool })
+		t.Assert(ok, false)
+	})
 }
 
 // Fix issue: https://github.com/gogf/gf/issues/819
@@ -314,6 +376,270 @@ func Test_isSubQuery(t *testing.T) {
 	})
 }
 
+func Test_replaceSubQuery(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		f := func(subQuery string) string {
+			return "SUB_QUERY"
+		}
+		t.Assert(replaceSubQuery("`user` u", f), "`user` u")
+		t.Assert(replaceSubQuery("(SELECT * FROM `user`) AS u", f), "(SUB_QUERY) AS u")
+		t.Assert(
+			replaceSubQuery("`user` u LEFT JOIN (SELECT uid FROM `detail` WHERE (id IN(SELECT 1))) d ON (d.uid=u.id)", f),
+			"`user` u LEFT JOIN (SUB_QUERY) d ON (d.uid=u.id)",
+		)
+		t.Assert(replaceSubQuery("((SELECT 1) UNION (SELECT 2)) t", f), "(SUB_QUERY) t")
+		t.Assert(replaceSubQuery("(SELECT 1", f), "(SELECT 1")
+	})
+}
+
+func Test_DriverMssql_parseSql_SubQuery(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		d := &DriverMssql{}
+		t.Assert(
+			d.parseSql("SELECT * FROM ((SELECT * FROM user WHERE id=@p1 LIMIT 1) UNION (SELECT * FROM user WHERE id=@p2 LIMIT 1)) union_alias"),
+			"SELECT * FROM ((SELECT TOP 1  * FROM user WHERE id=@p1 ) UNION (SELECT TOP 1  * FROM user WHERE id=@p2 )) union_alias",
+		)
+		t.Assert(
+			d.parseSql("SELECT * FROM ((SELECT * FROM user ORDER BY id LIMIT 2, 2) UNION (SELECT * FROM user)) union_alias LIMIT 1"),
+			"SELECT TOP 1  * FROM ((SELECT * FROM (SELECT ROW_NUMBER() OVER (ORDER BY  id ) as ROWNUMBER_,  * FROM user  ) as TMP_ WHERE TMP_.ROWNUMBER_ > 2 AND TMP_.ROWNUMBER_ <= 4) UNION (SELECT * FROM user)) union_alias ",
+		)
+	})
+}
+
+func Test_DriverMssql_parseSql_CTE(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		d := &DriverMssql{}
+		t.Assert(
+			d.parseSql("WITH t AS (SELECT * FROM user WHERE id>@p1) SELECT * FROM t ORDER BY id LIMIT 2, 2"),
+			"WITH t AS (SELECT * FROM user WHERE id>@p1) SELECT * FROM (SELECT ROW_NUMBER() OVER (ORDER BY  id ) as ROWNUMBER_,  * FROM t  ) as TMP_ WHERE TMP_.ROWNUMBER_ > 2 AND TMP_.ROWNUMBER_ <= 4",
+		)
+	})
+}
+
+func Test_DriverPgsql_FormatUpsert(t *testing.T) {
+	core := &Core{config: &ConfigNode{}}
+	core.DB = &DriverPgsql{Core: core}
+	columns := []string{"id", "passport", "nickname", "create_at"}
+	gtest.C(t, func(t *gtest.T) {
+		operation, upsert, err := core.DB.FormatUpsert("user", columns, DoInsertOption{
+			InsertOption: insertOptionSave,
+			OnConflict:   []string{"id"},
+		})
+		t.Assert(err, nil)
+		t.Assert(operation, "INSERT")
+		t.Assert(upsert, `ON CONFLICT ("id") DO UPDATE SET "passport"=EXCLUDED."passport","nickname"=EXCLUDED."nickname"`)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		operation, upsert, err := core.DB.FormatUpsert("user", columns, DoInsertOption{
+			InsertOption: insertOptionReplace,
+			OnConflict:   []string{"passport"},
+		})
+		t.Assert(err, nil)
+		t.Assert(operation, "INSERT")
+		t.Assert(upsert, `ON CONFLICT ("passport") DO UPDATE SET "id"=EXCLUDED."id","nickname"=EXCLUDED."nickname","create_at"=EXCLUDED."create_at"`)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		operation, upsert, err := core.DB.FormatUpsert("user", columns, DoInsertOption{
+			InsertOption: insertOptionIgnore,
+			OnConflict:   []string{"id"},
+		})
+		t.Assert(err, nil)
+		t.Assert(operation, "INSERT")
+		t.Assert(upsert, `ON CONFLICT ("id") DO NOTHING`)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		operation, upsert, err := core.DB.FormatUpsert("user", []string{"id"}, DoInsertOption{
+			InsertOption: insertOptionSave,
+			OnConflict:   []string{"id"},
+		})
+		t.Assert(err, nil)
+		t.Assert(operation, "INSERT")
+		t.Assert(upsert, `ON CONFLICT ("id") DO NOTHING`)
+	})
+}
+
+func Test_DriverMysql_FormatUpsert_OnDuplicate(t *testing.T) {
+	core := &Core{config: &ConfigNode{}}
+	core.DB = &DriverMysql{Core: core}
+	columns := []string{"id", "passport", "nickname", "count", "create_at"}
+	gtest.C(t, func(t *gtest.T) {
+		operation, upsert, err := core.DB.FormatUpsert("user", columns, DoInsertOption{
+			InsertOption: insertOptionSave,
+		})
+		t.Assert(err, nil)
+		t.Assert(operation, "INSERT")
+		t.Assert(upsert, "ON DUPLICATE KEY UPDATE `id`=VALUES(`id`),`passport`=VALUES(`passport`),`nickname`=VALUES(`nickname`),`count`=VALUES(`count`)")
+	})
+	gtest.C(t, func(t *gtest.T) {
+		operation, upsert, err := core.DB.FormatUpsert("user", columns, DoInsertOption{
+			InsertOption: insertOptionSave,
+			OnDuplicate:  []string{"nickname"},
+			OnDuplicateMap: Map{
+				"count":     Counter{Field: "count", Value: 1},
+				"passport":  Raw("CONCAT(`passport`,'_1')"),
+				"create_at": "passport",
+			},
+		})
+		t.Assert(err, nil)
+		t.Assert(operation, "INSERT")
+		t.Assert(upsert, "ON DUPLICATE KEY UPDATE `nickname`=VALUES(`nickname`),`count`=`count`+1,`create_at`=VALUES(`passport`),`passport`=CONCAT(`passport`,'_1')")
+	})
+	gtest.C(t, func(t *gtest.T) {
+		operation, upsert, err := core.DB.FormatUpsert("user", columns, DoInsertOption{
+			InsertOption:  insertOptionSave,
+			OnDuplicateEx: []string{"id", "passport"},
+		})
+		t.Assert(err, nil)
+		t.Assert(operation, "INSERT")
+		t.Assert(upsert, "ON DUPLICATE KEY UPDATE `nickname`=VALUES(`nickname`),`count`=VALUES(`count`)")
+	})
+	gtest.C(t, func(t *gtest.T) {
+		operation, upsert, err := core.DB.FormatUpsert("user", columns, DoInsertOption{
+			InsertOption:  insertOptionSave,
+			OnDuplicateEx: columns,
+		})
+		t.Assert(err, nil)
+		t.Assert(operation, "INSERT IGNORE")
+		t.Assert(upsert, "")
+	})
+}
+
+func Test_DriverPgsql_FormatUpsert_OnDuplicate(t *testing.T) {
+	core := &Core{config: &ConfigNode{}}
+	core.DB = &DriverPgsql{Core: core}
+	gtest.C(t, func(t *gtest.T) {
+		operation, upsert, err := core.DB.FormatUpsert(`"user"`, []string{"id", "count"}, DoInsertOption{
+			InsertOption:   insertOptionSave,
+			OnConflict:     []string{"id"},
+			OnDuplicateMap: Map{"count": &Counter{Value: -1}},
+		})
+		t.Assert(err, nil)
+		t.Assert(operation, "INSERT")
+		t.Assert(upsert, `ON CONFLICT ("id") DO UPDATE SET "count"="user"."count"+-1`)
+	})
+}
+
+func Test_Core_FormatReturning(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverMysql{Core: core}
+		_, _, err := core.DB.FormatReturning([]string{"id"})
+		t.AssertNE(err, nil)
+		t.Assert(core.DB.IsLastInsertIdSupported(), true)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverPgsql{Core: core}
+		output, returning, err := core.DB.FormatReturning([]string{"id", "name"})
+		t.Assert(err, nil)
+		t.Assert(output, "")
+		t.Assert(returning, `RETURNING "id","name"`)
+		t.Assert(core.DB.IsLastInsertIdSupported(), false)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverMssql{Core: core}
+		output, returning, err := core.DB.FormatReturning([]string{"*"})
+		t.Assert(err, nil)
+		t.Assert(output, "OUTPUT INSERTED.*")
+		t.Assert(returning, "")
+	})
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverOracle{Core: core}
+		output, returning, err := core.DB.FormatReturning([]string{"id", "name"})
+		t.Assert(err, nil)
+		t.Assert(output, "")
+		t.Assert(returning, `RETURNING "ID","NAME" INTO ?,?`)
+		_, _, err = core.DB.FormatReturning([]string{"*"})
+		t.AssertNE(err, nil)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		t.Assert(core.getReturningColumns(DoInsertOption{}), nil)
+		t.Assert(core.getReturningColumns(DoInsertOption{PrimaryKey: "id"}), []string{"id"})
+		t.Assert(core.getReturningColumns(DoInsertOption{Returning: []string{"name"}, PrimaryKey: "id"}), []string{"name", "id"})
+		t.Assert(core.getReturningColumns(DoInsertOption{Returning: []string{"*"}, PrimaryKey: "id"}), []string{"*"})
+	})
+}
+
+func Test_Core_getDoInsertOption(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverMysql{Core: core}
+		option := core.getDoInsertOption(insertOptionReplace)
+		t.Assert(option.InsertOption, insertOptionReplace)
+		t.Assert(option.BatchCount, defaultBatchNumber)
+		t.Assert(len(option.Returning), 0)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverMysql{Core: core}
+		core.ctx = WithDoInsertOption(context.Background(), DoInsertOption{
+			OnDuplicate: []string{"name"},
+			Returning:   []string{"id"},
+			BatchCount:  5,
+		})
+		option := core.getDoInsertOption(insertOptionSave)
+		t.Assert(option.InsertOption, insertOptionSave)
+		t.Assert(option.OnDuplicate, []string{"name"})
+		t.Assert(option.Returning, []string{"id"})
+		t.Assert(option.BatchCount, 5)
+		option = core.getDoInsertOption(insertOptionSave, 2)
+		t.Assert(option.BatchCount, 2)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(len(DoInsertOptionFromCtx(nil).Returning), 0)
+		ctx := context.WithValue(context.Background(), "DoInsertOptionForContext", DoInsertOption{Returning: []string{"id"}})
+		t.Assert(len(DoInsertOptionFromCtx(ctx).Returning), 0)
+		ctx = WithDoInsertOption(ctx, DoInsertOption{Returning: []string{"id"}})
+		t.Assert(DoInsertOptionFromCtx(ctx).Returning, []string{"id"})
+	})
+}
+
+func Test_Model_isReturningOnDemand(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverMssql{Core: core}
+		model := &Model{db: core.DB}
+		t.Assert(model.isReturningOnDemand(), true)
+		option := model.getDoInsertOption(insertOptionDefault)
+		t.Assert(option.PrimaryKey, "")
+		t.Assert(len(option.Returning), 0)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverPgsql{Core: core}
+		model := &Model{db: core.DB}
+		t.Assert(model.isReturningOnDemand(), false)
+	})
+}
+
+func Test_SqlResult_Returning(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		r := &SqlResult{
+			affected:    2,
+			insertIdKey: "id",
+			returning: Result{
+				Record{"ID": gvar.New(1), "NAME": gvar.New("john")},
+				Record{"ID": gvar.New(2), "NAME": gvar.New("smith")},
+			},
+		}
+		id, err := r.LastInsertId()
+		t.Assert(err, nil)
+		t.Assert(id, 2)
+		t.Assert(r.MustGetAffected(), 2)
+		t.Assert(r.Returning()[1]["NAME"], "smith")
+	})
+	gtest.C(t, func(t *gtest.T) {
+		r := &SqlResult{insertIdKey: "id"}
+		id, err := r.LastInsertId()
+		t.Assert(err, nil)
+		t.Assert(id, 0)
+		t.Assert(r.MustGetAffected(), 0)
+	})
+}
+
 func TestResult_Structs1(t *testing.T) {
 	type A struct {
 		Id int `orm:"id"`
@@ -336,3 +662,88 @@ func TestResult_Structs1(t *testing.T) {
 		t.Assert(array[1].Name, "smith")
 	})
 }
+
+type retryablePgsqlError string
+
+func (e retryablePgsqlError) Error() string    { return string(e) }
+func (e retryablePgsqlError) SQLState() string { return string(e) }
+
+type retryableMssqlError int32
+
+func (e retryableMssqlError) Error() string         { return fmt.Sprint(int32(e)) }
+func (e retryableMssqlError) SQLErrorNumber() int32 { return int32(e) }
+
+// retryableSqliteError has the same fields as sqlite3.Error.
+type retryableSqliteError struct {
+	Code         int
+	ExtendedCode int
+}
+
+func (e retryableSqliteError) Error() string { return fmt.Sprint(e.Code) }
+
+func Test_Driver_IsRetryableError(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverMysql{Core: core}
+		t.Assert(core.DB.IsRetryableError(&mysql.MySQLError{Number: 1213}), true)
+		t.Assert(core.DB.IsRetryableError(&mysql.MySQLError{Number: 1062}), false)
+		t.Assert(core.DB.IsRetryableError(errors.New("1213")), false)
+		t.Assert(core.DB.IsRetryableError(nil), false)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverPgsql{Core: core}
+		t.Assert(core.DB.IsRetryableError(retryablePgsqlError("40001")), true)
+		t.Assert(core.DB.IsRetryableError(retryablePgsqlError("40P01")), true)
+		t.Assert(core.DB.IsRetryableError(retryablePgsqlError("23505")), false)
+		t.Assert(core.DB.IsRetryableError(nil), false)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverMssql{Core: core}
+		t.Assert(core.DB.IsRetryableError(retryableMssqlError(1205)), true)
+		t.Assert(core.DB.IsRetryableError(retryableMssqlError(2627)), false)
+		t.Assert(core.DB.IsRetryableError(nil), false)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{}
+		core.DB = &DriverSqlite{Core: core}
+		t.Assert(core.DB.IsRetryableError(retryableSqliteError{Code: 5}), true)
+		t.Assert(core.DB.IsRetryableError(&retryableSqliteError{Code: 6}), true)
+		t.Assert(core.DB.IsRetryableError(retryableSqliteError{Code: 19}), false)
+		t.Assert(core.DB.IsRetryableError(errors.New("database is locked")), false)
+		t.Assert(core.DB.IsRetryableError(nil), false)
+	})
+}
+
+func Test_formatError(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		driverErr := errors.New("driver error")
+		err := formatError(driverErr, "SELECT * FROM user WHERE id=?", 1)
+		t.Assert(err.Error(), "driver error, SELECT * FROM user WHERE id=1\n")
+		t.Assert(gerror.Cause(err), driverErr)
+		t.Assert(errors.Is(err, driverErr), true)
+		t.Assert(fmt.Sprintf("%v", err), err.Error())
+		t.Assert(formatError(ErrNoRows, "SELECT 1"), ErrNoRows)
+		t.Assert(formatError(nil, "SELECT 1"), nil)
+	})
+}
+
+func Test_Model_removeCtePrefix(t *testing.T) {
+	gtest.C(t, func(t *gtest.T) {
+		core := &Core{config: &ConfigNode{Prefix: "gf_"}}
+		core.DB = &DriverMysql{Core: core}
+		model := core.DB.Model("uc c").LeftJoin("gf_uc u1", "u1.id=c.id").LeftJoin("uc u2", "u2.id=c.id")
+		t.Assert(model.tables, "`gf_uc` c LEFT JOIN `gf_uc` u1 ON (u1.id=c.id) LEFT JOIN `gf_uc` u2 ON (u2.id=c.id)")
+		t.Assert(len(model.tableRefs), 2)
+		model.removeCtePrefix([]string{"uc"})
+		t.Assert(model.tables, "`uc` c LEFT JOIN `gf_uc` u1 ON (u1.id=c.id) LEFT JOIN `uc` u2 ON (u2.id=c.id)")
+		t.Assert(len(model.tableRefs), 0)
+		t.Assert(model.isCteTable("`uc`"), false)
+		model.cteNames = []string{"uc"}
+		t.Assert(model.isCteTable("`uc`"), true)
+		t.Assert(model.isCteTable("`gf_uc`"), false)
+		model = model.LeftJoin("uc u3", "u3.id=c.id")
+		t.Assert(gstr.HasSuffix(model.tables, "LEFT JOIN `uc` u3 ON (u3.id=c.id)"), true)
+	})
+}
@@ -7,6 +7,7 @@
 package gdb_test
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/gogf/gf/container/garray"
@@ -456,6 +457,100 @@ func Test_Model_Save(t *testing.T) {
 	})
 }
 
+func Test_Model_OnDuplicate(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+	// string.
+	gtest.C(t, func(t *gtest.T) {
+		data := g.Map{
+			"id":          1,
+			"passport":    "pp1",
+			"password":    "pw1",
+			"nickname":    "n1",
+			"create_time": "2016-06-06",
+		}
+		_, err := db.Model(table).OnDuplicate("passport,password").Data(data).Save()
+		t.Assert(err, nil)
+		one, err := db.Model(table).FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one["passport"], data["passport"])
+		t.Assert(one["password"], data["password"])
+		t.Assert(one["nickname"], "name_1")
+	})
+	// map.
+	gtest.C(t, func(t *gtest.T) {
+		data := g.Map{
+			"id":          1,
+			"passport":    "pp2",
+			"password":    "pw2",
+			"nickname":    "n2",
+			"create_time": "2016-06-06",
+		}
+		_, err := db.Model(table).OnDuplicate(g.Map{
+			"passport": "nickname",
+			"password": gdb.Raw("CONCAT(VALUES(`password`),'_raw')"),
+			"id":       gdb.Counter{Field: "id", Value: 100},
+		}).Data(data).Save()
+		t.Assert(err, nil)
+		one, err := db.Model(table).FindOne(101)
+		t.Assert(err, nil)
+		t.Assert(one["passport"], "n2")
+		t.Assert(one["password"], "pw2_raw")
+		t.Assert(one["nickname"], "name_1")
+	})
+	// exclusion.
+	gtest.C(t, func(t *gtest.T) {
+		data := g.List{
+			g.Map{
+				"id":          2,
+				"passport":    "pp3",
+				"password":    "pw3",
+				"nickname":    "n3",
+				"create_time": "2016-06-06",
+			},
+			g.Map{
+				"id":          3,
+				"passport":    "pp4",
+				"password":    "pw4",
+				"nickname":    "n4",
+				"create_time": "2016-06-06",
+			},
+		}
+		_, err := db.Model(table).OnDuplicateEx("passport,create_time").Data(data).Save()
+		t.Assert(err, nil)
+		all, err := db.Model(table).Where("id", g.Slice{2, 3}).Order("id asc").All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 2)
+		t.Assert(all[0]["passport"], "user_2")
+		t.Assert(all[0]["nickname"], "n3")
+		t.Assert(all[1]["passport"], "user_3")
+		t.Assert(all[1]["password"], "pw4")
+	})
+}
+
+func Test_Model_Returning(t *testing.T) {
+	table := createTable()
+	defer dropTable(table)
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Model(table).Returning("id").Data(g.Map{
+			"id":       1,
+			"passport": "t1",
+			"nickname": "T1",
+		}).Insert()
+		t.AssertNE(err, nil)
+
+		result, err := db.Model(table).Data(g.Map{
+			"id":       2,
+			"passport": "t2",
+			"nickname": "T2",
+		}).Insert()
+		t.Assert(err, nil)
+		id, err := result.LastInsertId()
+		t.Assert(err, nil)
+		t.Assert(id, 2)
+	})
+}
+
 func Test_Model_Update(t *testing.T) {
 	table := createInitTable()
 	defer dropTable(table)
@@ -508,6 +603,102 @@ func Test_Model_Update(t *testing.T) {
 	})
 }
 
+func Test_Model_Increment(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Model(table).Increment("id", 100)
+		t.AssertNE(err, nil)
+
+		result, err := db.Model(table).Where("id", 1).Increment("id", 100)
+		t.Assert(err, nil)
+		n, _ := result.RowsAffected()
+		t.Assert(n, 1)
+		count, err := db.Model(table).Where("id", 101).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 1)
+
+		_, err = db.Model(table).Where("id", 101).Decrement("id", 90)
+		t.Assert(err, nil)
+		one, err := db.Model(table).Where("id", 11).One()
+		t.Assert(err, nil)
+		t.Assert(one["passport"], "user_1")
+	})
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Model(table).Where("id", 2).Increments(map[string]float64{"id": 200})
+		t.Assert(err, nil)
+		_, err = db.Model(table).Where("id", 202).Decrements(map[string]float64{"id": 100})
+		t.Assert(err, nil)
+		one, err := db.Model(table).Where("id", 102).One()
+		t.Assert(err, nil)
+		t.Assert(one["passport"], "user_2")
+
+		_, err = db.Model(table).Where("id", 102).Increments(nil)
+		t.AssertNE(err, nil)
+	})
+}
+
+func Test_Model_OptimisticLock(t *testing.T) {
+	table := "version_test_table_" + gtime.TimestampNanoStr()
+	if _, err := db.Exec(fmt.Sprintf(`
+CREATE TABLE %s (
+  id       int(11) NOT NULL,
+  nickname varchar(45) DEFAULT NULL,
+  revision int(11) NOT NULL DEFAULT 0,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+    `, table)); err != nil {
+		gtest.Error(err)
+	}
+	defer dropTable(table)
+
+	type User struct {
+		Id       int    `orm:"id"`
+		Nickname string `orm:"nickname"`
+		Revision int    `orm:"revision,version"`
+	}
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Model(table).Data(g.List{
+			{"id": 1, "nickname": "name_1"},
+			{"id": 2, "nickname": "name_2"},
+		}).Insert()
+		t.Assert(err, nil)
+
+		user := &User{}
+		err = db.Model(table).Where("id", 1).Struct(user)
+		t.Assert(err, nil)
+		t.Assert(user.Revision, 0)
+
+		user.Nickname = "name_100"
+		_, err = db.Model(table).Data(user).Where("id", 1).Update()
+		t.Assert(err, nil)
+		one, err := db.Model(table).Where("id", 1).One()
+		t.Assert(err, nil)
+		t.Assert(one["nickname"], "name_100")
+		t.Assert(one["revision"], 1)
+
+		// The version has been changed.
+		user.Nickname = "name_200"
+		_, err = db.Model(table).Data(user).Where("id", 1).Update()
+		t.Assert(err, gdb.ErrOptimisticLock)
+		one, err = db.Model(table).Where("id", 1).One()
+		t.Assert(err, nil)
+		t.Assert(one["nickname"], "name_100")
+		t.Assert(one["revision"], 1)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		// The version condition wraps all the conditions.
+		user := &User{Id: 2, Nickname: "name_300", Revision: 0}
+		_, err := db.Model(table).Data(user).Where("id", 1).Or("id", 2).Update()
+		t.Assert(err, nil)
+		all, err := db.Model(table).Order("id asc").All()
+		t.Assert(err, nil)
+		t.Assert(all[0]["nickname"], "name_100")
+		t.Assert(all[1]["nickname"], "name_300")
+		t.Assert(all[1]["revision"], 1)
+	})
+}
+
 func Test_Model_Clone(t *testing.T) {
 	table := createInitTable()
 	defer dropTable(table)
@@ -922,16 +1113,22 @@ func Test_Model_Count(t *testing.T) {
 		t.Assert(err, nil)
 		t.Assert(count, SIZE)
 	})
-	//gtest.C(t, func(t *gtest.T) {
-	//	count, err := db.Table(table).Fields("id myid").Where("id>8").Count()
-	//	t.Assert(err, nil)
-	//	t.Assert(count, 2)
-	//})
-	//gtest.C(t, func(t *gtest.T) {
-	//	count, err := db.Table(table).As("u1").LeftJoin(table, "u2", "u2.id=u1.id").Fields("u2.id u2id").Where("u1.id>8").Count()
-	//	t.Assert(err, nil)
-	//	t.Assert(count, 2)
-	//})
+	gtest.C(t, func(t *gtest.T) {
+		count, err := db.Table(table).Fields("id myid").Where("id>8").Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		count, err := db.Table(table).As("u1").LeftJoin(table, "u2", "u2.id=u1.id").Fields("u2.id u2id").Where("u1.id>8").Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+	})
+	// COUNT...ORDER BY...
+	gtest.C(t, func(t *gtest.T) {
+		count, err := db.Table(table).Group("nickname").Order("id desc").Count()
+		t.Assert(err, nil)
+		t.Assert(count, SIZE)
+	})
 }
 
 func Test_Model_FindCount(t *testing.T) {
@@ -2778,6 +2975,28 @@ func Test_Model_Distinct(t *testing.T) {
 		t.Assert(err, nil)
 		t.Assert(len(all), 2)
 	})
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Table(table).Data(g.Map{"nickname": "name_10"}).Where("id>?", 8).Update()
+		t.Assert(err, nil)
+
+		all, err := db.Table(table).Distinct().Fields("nickname").Where("id>?", 7).Order("nickname asc").All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 2)
+		t.Assert(all[0]["nickname"], "name_10")
+		t.Assert(all[1]["nickname"], "name_8")
+
+		count, err := db.Table(table).Distinct().Fields("nickname n").Where("id>?", 7).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+
+		count, err = db.Table(table).Distinct().Fields("nickname, passport").Where("id>?", 7).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+
+		count, err = db.Table(table).Distinct().Where("id>?", 7).CountColumn("nickname")
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+	})
 }
 
 func Test_Model_Min_Max(t *testing.T) {
@@ -3321,3 +3540,661 @@ func Test_Model_Fields_AutoFilterInJoinStatement(t *testing.T) {
 		t.Assert(one["number"].String(), "n")
 	})
 }
+
+func Test_Model_WhereBuilder(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		b := gdb.NewWhereBuilder()
+		where, args := b.Where("id", 1).Or("id", 2).Where("nickname", "name_2").Build(db)
+		t.Assert(where, "((`id`=?) OR (`id`=?)) AND (`nickname`=?)")
+		t.Assert(args, g.Slice{1, 2, "name_2"})
+
+		where, args = b.Where("id>?", 1).Where(b.Where("id", 2).Or("id IN(?)", g.Slice{3, 4})).Build(db)
+		t.Assert(where, "(id>?) AND ((`id`=?) OR (id IN(?,?)))")
+		t.Assert(args, g.Slice{1, 2, 3, 4})
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		var (
+			b     = gdb.NewWhereBuilder()
+			group = b.Where("id", 1).Or("id", 3)
+		)
+		// id > 1 AND (id = 1 OR id = 3)
+		all, err := db.Model(table).Where("id>?", 1).Where(group).Order("id asc").All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 1)
+		t.Assert(all[0]["id"].Int(), 3)
+
+		// The builder can be reused across models and nested.
+		count, err := db.Model(table).Where(b.Where(group).Or(b.Where("id", 5).Where("nickname", "name_5"))).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+
+		count, err = db.Model(table).Where("id", 2).Or(group).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+	})
+}
+
+func Test_Model_WhereTyped(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		count, err := db.Model(table).WhereIn("id", g.Slice{1, 2, 3}).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+
+		count, err = db.Model(table).WhereIn("id", g.Slice{}).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 0)
+
+		count, err = db.Model(table).WhereNotIn("id", g.Slice{1, 2, 3}).Count()
+		t.Assert(err, nil)
+		t.Assert(count, SIZE-3)
+
+		count, err = db.Model(table).WhereNotIn("id", g.Slice{}).Count()
+		t.Assert(err, nil)
+		t.Assert(count, SIZE)
+
+		count, err = db.Model(table).WhereIn("id", g.Slice{1}).WhereOrNotIn("id", g.Slice{}).Count()
+		t.Assert(err, nil)
+		t.Assert(count, SIZE)
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		count, err := db.Model(table).WhereBetween("id", 2, 5).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 4)
+
+		count, err = db.Model(table).WhereNotBetween("id", 2, 5).Count()
+		t.Assert(err, nil)
+		t.Assert(count, SIZE-4)
+
+		count, err = db.Model(table).WhereBetween("id", 1, 2).WhereOrBetween("id", 9, 10).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 4)
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		count, err := db.Model(table).WhereLike("nickname", "name_1%").Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+
+		count, err = db.Model(table).WhereNotLike("nickname", "name_1%").Count()
+		t.Assert(err, nil)
+		t.Assert(count, SIZE-2)
+
+		count, err = db.Model(table).WhereLike("nickname", "name_1").WhereOrLike("nickname", "name_2").Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Model(table).Data("passport", nil).Where("id", 1).Update()
+		t.Assert(err, nil)
+
+		count, err := db.Model(table).WhereNull("passport").Count()
+		t.Assert(err, nil)
+		t.Assert(count, 1)
+
+		count, err = db.Model(table).WhereNotNull("passport").Count()
+		t.Assert(err, nil)
+		t.Assert(count, SIZE-1)
+
+		count, err = db.Model(table).WhereNull("passport").WhereOrNull("nickname").Count()
+		t.Assert(err, nil)
+		t.Assert(count, 1)
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		count, err := db.Model(table).WhereGT("id", 8).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+
+		count, err = db.Model(table).WhereGTE("id", 8).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+
+		count, err = db.Model(table).WhereLT("id", 3).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+
+		count, err = db.Model(table).WhereLTE("id", 3).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+
+		count, err = db.Model(table).WhereLT("id", 2).WhereOrGT("id", 9).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+
+		count, err = db.Model(table).WhereLTE("id", 2).WhereOrGTE("id", 9).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 4)
+
+		count, err = db.Model(table).WhereGT(table+".id", 5).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 5)
+	})
+}
+
+func Test_Model_SubQuery(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	// Sub query as table.
+	gtest.C(t, func(t *gtest.T) {
+		sub := db.Model(table).Where("id>?", 5).As("t")
+		count, err := db.Model(sub).Where("t.id<?", 8).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+
+		all, err := db.Model(db.Model(table).Fields("id,nickname").Where("id<?", 4), "t").Order("t.id desc").All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 3)
+		t.Assert(all[0]["id"].Int(), 3)
+		t.Assert(all[0]["nickname"].String(), "name_3")
+	})
+
+	// Sub query in join.
+	gtest.C(t, func(t *gtest.T) {
+		all, err := db.Model(table, "u").
+			Fields("u.id").
+			InnerJoin(db.Model(table).Where("id IN(?)", g.Slice{2, 4, 6}), "t", "t.id=u.id").
+			Where("u.id>?", 3).
+			Order("u.id asc").
+			All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 2)
+		t.Assert(all[0]["id"].Int(), 4)
+		t.Assert(all[1]["id"].Int(), 6)
+	})
+
+	// Sub query with arguments in table for writing operations.
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Model(table, "u").
+			InnerJoin(db.Model(table).Where("id IN(?)", g.Slice{2, 4, 6}), "t", "t.id=u.id").
+			Data("u.nickname", "john").
+			Where("u.id>?", 3).
+			Update()
+		t.AssertNE(err, nil)
+	})
+
+	// Sub query in condition.
+	gtest.C(t, func(t *gtest.T) {
+		sub := db.Model(table).Fields("id").Where("id>?", 7)
+		count, err := db.Model(table).Where("id>?", 1).WhereIn("id", sub).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+
+		count, err = db.Model(table).WhereNotIn("id", sub).Where("id<?", 3).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+
+		value, err := db.Model(table).Fields("nickname").Where("id=?", db.Model(table).Fields("MAX(id)")).Value()
+		t.Assert(err, nil)
+		t.Assert(value.String(), "name_10")
+	})
+
+	// Sub query in fields.
+	gtest.C(t, func(t *gtest.T) {
+		sub := db.Model(table).Fields("COUNT(1)").Where("id<?", 4).As("cnt")
+		one, err := db.Model(table).Fields("id", sub).Where("id=?", 5).One()
+		t.Assert(err, nil)
+		t.Assert(one["id"].Int(), 5)
+		t.Assert(one["cnt"].Int(), 3)
+	})
+}
+
+func Test_Model_SubQuery_SoftDeleting(t *testing.T) {
+	table := "time_test_table_" + gtime.TimestampNanoStr()
+	if _, err := db.Exec(fmt.Sprintf(`
+CREATE TABLE %s (
+  id         int(11) NOT NULL,
+  name       varchar(45) DEFAULT NULL,
+  deleted_at datetime DEFAULT NULL,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+    `, table)); err != nil {
+		gtest.Error(err)
+	}
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		for i := 1; i <= 3; i++ {
+			_, err := db.Model(table).Data(g.Map{"id": i, "name": fmt.Sprintf(`name_%d`, i)}).Insert()
+			t.Assert(err, nil)
+		}
+		_, err := db.Model(table).Delete("id", 2)
+		t.Assert(err, nil)
+
+		count, err := db.Model(db.Model(table).As("t")).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+
+		count, err = db.Model(db.Model(table).Unscoped().As("t")).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+
+		all, err := db.Model(table, "u").
+			Fields("u.id").
+			LeftJoin(db.Model(table).Fields("id"), "t", "t.id=u.id").
+			Unscoped().
+			WhereNull("t.id").
+			All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 1)
+		t.Assert(all[0]["id"].Int(), 2)
+	})
+}
+
+func Test_Model_Union(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		all, err := db.Union(
+			db.Model(table).Where("id", 1),
+			db.Model(table).Where("id IN(?)", g.Slice{1, 2}),
+		).Order("id asc").All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 2)
+		t.Assert(all[0]["id"].Int(), 1)
+		t.Assert(all[1]["id"].Int(), 2)
+
+		count, err := db.UnionAll(
+			db.Model(table).Where("id", 1),
+			db.Model(table).Where("id IN(?)", g.Slice{1, 2}),
+		).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+	})
+
+	// Sub models with their own order and limit.
+	gtest.C(t, func(t *gtest.T) {
+		type User struct {
+			Id       int
+			Passport string
+		}
+		var users []*User
+		err := db.UnionAll(
+			db.Model(table).Order("id asc").Limit(2),
+			db.Model(table).Order("id desc").Limit(2),
+		).Where("id>?", 1).Order("id desc").Scan(&users)
+		t.Assert(err, nil)
+		t.Assert(len(users), 3)
+		t.Assert(users[0].Id, 10)
+		t.Assert(users[1].Id, 9)
+		t.Assert(users[2].Id, 2)
+
+		value, err := db.Union(
+			db.Model(table).Fields("id").Where("id<?", 3),
+			db.Model(table).Fields("id").Where("id>?", 8),
+		).Order("id desc").Limit(1, 1).Value("id")
+		t.Assert(err, nil)
+		t.Assert(value.Int(), 9)
+	})
+}
+
+func Test_Model_WithCTE(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		all, err := db.Model("t").WithCTE("t", db.Model(table).Where("id>?", 6)).Where("id<?", 9).Order("id asc").All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 2)
+		t.Assert(all[0]["id"].Int(), 7)
+		t.Assert(all[1]["id"].Int(), 8)
+
+		count, err := db.Model("t").WithCTE("t", db.Model(table).Where("id>?", 6)).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+	})
+
+	// Recursive common table expression.
+	gtest.C(t, func(t *gtest.T) {
+		model := db.Model("t").WithRecursiveCTE(
+			"t",
+			db.Model(table).Fields("id").Where("id", 2),
+			db.Model(table+" c").Fields("c.id").InnerJoin("t", "c.id=t.id+1").Where("c.id<?", 6),
+		)
+		count, err := model.Count()
+		t.Assert(err, nil)
+		t.Assert(count, 4)
+
+		array, err := model.Where("id>?", 2).Order("id desc").Array()
+		t.Assert(err, nil)
+		t.Assert(array, g.Slice{5, 4, 3})
+	})
+}
+
+func Test_Model_WithCTE_Prefix(t *testing.T) {
+	db := dbPrefix
+	table := fmt.Sprintf(`%s_%d`, TABLE, gtime.TimestampNano())
+	createInitTableWithDb(db, PREFIX1+table)
+	defer dropTable(PREFIX1 + table)
+
+	gtest.C(t, func(t *gtest.T) {
+		count, err := db.Model("t").WithCTE("t", db.Model(table).Where("id>?", 6)).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 4)
+
+		array, err := db.Model("t").
+			WithCTE("t", db.Model(table).Where("id>?", 6)).
+			LeftJoin("t t2", "t2.id=t.id+1").
+			Fields("t.id").
+			Where("t2.id IS NULL").
+			Array()
+		t.Assert(err, nil)
+		t.Assert(array, g.Slice{10})
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		array, err := db.Model("t").WithRecursiveCTE(
+			"t",
+			db.Model(table).Fields("id").Where("id", 2),
+			db.Model(table+" c").Fields("c.id").InnerJoin("t", "c.id=t.id+1").Where("c.id<?", 6),
+		).Order("id desc").Array()
+		t.Assert(err, nil)
+		t.Assert(array, g.Slice{5, 4, 3, 2})
+	})
+
+	// The common table expression has the same name as the table without prefix,
+	// the table referenced in its query is the real table.
+	gtest.C(t, func(t *gtest.T) {
+		count, err := db.Model(table).WithCTE(table, db.Model(table).Where("id>?", 6)).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 4)
+
+		array, err := db.Model(table).WithRecursiveCTE(
+			table,
+			db.Model(table).Fields("id").Where("id", 2),
+			db.Model(PREFIX1+table+" c").Fields("c.id").InnerJoin(table+" t", "c.id=t.id+1").Where("c.id<?", 6),
+		).Order("id desc").Array()
+		t.Assert(err, nil)
+		t.Assert(array, g.Slice{5, 4, 3, 2})
+	})
+}
+
+func Test_Model_Aggregate(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		sum, err := db.Model(table).Sum("id")
+		t.Assert(err, nil)
+		t.Assert(sum, 55)
+
+		avg, err := db.Model(table).Where("id<?", 4).Avg("id")
+		t.Assert(err, nil)
+		t.Assert(avg, 2)
+
+		min, err := db.Model(table).Where("id>?", 3).Min("id")
+		t.Assert(err, nil)
+		t.Assert(min.Int(), 4)
+
+		max, err := db.Model(table).Max("passport")
+		t.Assert(err, nil)
+		t.Assert(max.String(), "user_9")
+
+		count, err := db.Model(table).Where("id>?", 5).CountColumn("passport")
+		t.Assert(err, nil)
+		t.Assert(count, 5)
+	})
+
+	// No matched records.
+	gtest.C(t, func(t *gtest.T) {
+		sum, err := db.Model(table).Where("id>?", 100).Sum("id")
+		t.Assert(err, nil)
+		t.Assert(sum, 0)
+
+		max, err := db.Model(table).Where("id>?", 100).Max("id")
+		t.Assert(err, nil)
+		t.Assert(max.IsNil(), true)
+	})
+
+	// Group by.
+	gtest.C(t, func(t *gtest.T) {
+		sum, err := db.Model(table).Group("id").Having("id>?", 8).Sum("id")
+		t.Assert(err, nil)
+		t.Assert(sum, 19)
+
+		count, err := db.Model(table).Group("id").Having("id>?", 8).CountColumn("id")
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+	})
+
+	// Group by with groups of different sizes.
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Model(table).Data("nickname", "a").Where("id<=?", 2).Update()
+		t.Assert(err, nil)
+		_, err = db.Model(table).Data("nickname", "b").Where("id>?", 2).Update()
+		t.Assert(err, nil)
+
+		avg, err := db.Model(table).Group("nickname").Avg("id")
+		t.Assert(err, nil)
+		t.Assert(avg, 5.5)
+
+		sum, err := db.Model(table).Group("nickname").Sum("id")
+		t.Assert(err, nil)
+		t.Assert(sum, 55)
+
+		min, err := db.Model(table).Group("nickname").Having("COUNT(*)>?", 2).Min("id")
+		t.Assert(err, nil)
+		t.Assert(min.Int(), 3)
+
+		avg, err = db.Model(table).Group("nickname").Having("COUNT(*)>?", 2).Avg("id")
+		t.Assert(err, nil)
+		t.Assert(avg, 6.5)
+
+		count, err := db.Model(table).Group("nickname").CountColumn("id")
+		t.Assert(err, nil)
+		t.Assert(count, 10)
+	})
+}
+
+func Test_Model_Paginate(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	type User struct {
+		Id       int
+		Passport string
+	}
+	gtest.C(t, func(t *gtest.T) {
+		var users []*User
+		total, err := db.Model(table).Where("id>?", 2).Order("id desc").Paginate(2, 3, &users)
+		t.Assert(err, nil)
+		t.Assert(total, 8)
+		t.Assert(len(users), 3)
+		t.Assert(users[0].Id, 7)
+		t.Assert(users[2].Id, 5)
+	})
+	// Concurrently.
+	gtest.C(t, func(t *gtest.T) {
+		var users []*User
+		total, err := db.Model(table).Where("id>?", 2).Order("id desc").Paginate(3, 3, &users, true)
+		t.Assert(err, nil)
+		t.Assert(total, 8)
+		t.Assert(len(users), 2)
+		t.Assert(users[1].Id, 3)
+	})
+	// Cache.
+	gtest.C(t, func(t *gtest.T) {
+		var (
+			users []*User
+			model = db.Model(table).Safe().Where("id>?", 2).Cache(time.Second, "test_paginate").Limit(2)
+		)
+		total, err := model.ScanAndCount(&users)
+		t.Assert(err, nil)
+		t.Assert(total, 8)
+		t.Assert(len(users), 2)
+
+		_, err = db.Model(table).Delete("id", 10)
+		t.Assert(err, nil)
+
+		users = nil
+		total, err = model.ScanAndCount(&users)
+		t.Assert(err, nil)
+		t.Assert(total, 8)
+		t.Assert(len(users), 2)
+	})
+	// Transaction.
+	gtest.C(t, func(t *gtest.T) {
+		err := db.Transaction(func(tx *gdb.TX) error {
+			var users []*User
+			total, err := tx.Model(table).Where("id>?", 2).Paginate(1, 3, &users, true)
+			t.Assert(err, nil)
+			t.Assert(total, 7)
+			t.Assert(len(users), 3)
+			return nil
+		})
+		t.Assert(err, nil)
+	})
+}
+
+func Test_Model_After(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		all, err := db.Model(table).After("id").Limit(3).All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 3)
+		t.Assert(all[0]["id"].Int(), 1)
+
+		all, err = db.Model(table).After("id", 3).Limit(3).All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 3)
+		t.Assert(all[0]["id"].Int(), 4)
+		t.Assert(all[2]["id"].Int(), 6)
+	})
+	// Composite keys.
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Model(table).Data(g.Map{"nickname": "name"}).Where("id>?", 5).Update()
+		t.Assert(err, nil)
+
+		all, err := db.Model(table).Where("id<?", 9).After("nickname,id", "name", 6).Limit(3).All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 3)
+		t.Assert(all[0]["id"].Int(), 7)
+		t.Assert(all[1]["id"].Int(), 8)
+		t.Assert(all[2]["id"].Int(), 1)
+	})
+
+	// Mismatched count of values and columns.
+	gtest.C(t, func(t *gtest.T) {
+		all, err := db.Model(table).After("nickname,id", "name").Limit(3).All()
+		t.AssertNE(err, nil)
+		t.Assert(len(all), 0)
+
+		one, err := db.Model(table).After("id", 1, 2).One()
+		t.AssertNE(err, nil)
+		t.Assert(one.IsEmpty(), true)
+
+		_, err = db.Model(table).After("id", 1, 2).Data("nickname", "name").Update()
+		t.AssertNE(err, nil)
+	})
+}
+
+func Test_Model_ChunkById(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		var ids []int
+		err := db.Model(table).Where("id>?", 1).ChunkById("id", 4, func(result gdb.Result) error {
+			for _, record := range result {
+				ids = append(ids, record["id"].Int())
+			}
+			// Deleting records does not affect the following chunks.
+			_, err := db.Model(table).Delete("id", result[0]["id"])
+			return err
+		})
+		t.Assert(err, nil)
+		t.Assert(ids, g.Slice{2, 3, 4, 5, 6, 7, 8, 9, 10})
+	})
+	gtest.C(t, func(t *gtest.T) {
+		n := 0
+		err := db.Model(table).ChunkById("id", 2, func(result gdb.Result) error {
+			n++
+			return fmt.Errorf("stop")
+		})
+		t.Assert(err.Error(), "stop")
+		t.Assert(n, 1)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		err := db.Model(table).Fields("passport").ChunkById("id", 2, func(result gdb.Result) error {
+			return nil
+		})
+		t.AssertNE(err, nil)
+	})
+}
+
+func Test_Model_Cursor(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	type User struct {
+		Id       int
+		Passport string
+	}
+	gtest.C(t, func(t *gtest.T) {
+		cursor, err := db.Model(table).Where("id>?", 7).Order("id asc").Cursor()
+		t.Assert(err, nil)
+		defer cursor.Close()
+
+		var users []*User
+		for cursor.Next() {
+			user := new(User)
+			t.Assert(cursor.Scan(user), nil)
+			users = append(users, user)
+		}
+		t.Assert(cursor.Err(), nil)
+		t.Assert(len(users), 3)
+		t.Assert(users[0].Id, 8)
+		t.Assert(users[2].Passport, "user_10")
+		t.Assert(cursor.Next(), false)
+	})
+	// Context cancellation.
+	gtest.C(t, func(t *gtest.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cursor, err := db.Ctx(ctx).Model(table).Cursor()
+		t.Assert(err, nil)
+		defer cursor.Close()
+
+		t.Assert(cursor.Next(), true)
+		cancel()
+		time.Sleep(100 * time.Millisecond)
+		for cursor.Next() {
+		}
+		t.AssertNE(cursor.Err(), nil)
+	})
+}
+
+func Test_Model_Iterate(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		var ids []int
+		err := db.Model(table).Where("id<?", 4).Order("id asc").Iterate(func(record gdb.Record) error {
+			ids = append(ids, record["id"].Int())
+			return nil
+		})
+		t.Assert(err, nil)
+		t.Assert(ids, g.Slice{1, 2, 3})
+	})
+	gtest.C(t, func(t *gtest.T) {
+		n := 0
+		err := db.Model(table).Iterate(func(record gdb.Record) error {
+			n++
+			return fmt.Errorf("stop")
+		})
+		t.Assert(err.Error(), "stop")
+		t.Assert(n, 1)
+	})
+}
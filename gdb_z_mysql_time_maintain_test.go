@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gogf/gf/database/gdb"
 	"github.com/gogf/gf/frame/g"
 
 	"github.com/gogf/gf/test/gtest"
@@ -605,6 +606,278 @@ CREATE TABLE %s (
 	})
 }
 
+func Test_SoftDelete_Strategy(t *testing.T) {
+	table := "time_test_table_" + gtime.TimestampNanoStr()
+	if _, err := db.Exec(fmt.Sprintf(`
+CREATE TABLE %s (
+  id        int(11) NOT NULL,
+  name      varchar(45) DEFAULT NULL,
+  delete_at tinyint(1) NOT NULL DEFAULT 0,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+    `, table)); err != nil {
+		gtest.Error(err)
+	}
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		r, err := db.Table(table).Data(g.List{
+			{"id": 1, "name": "name_1"},
+			{"id": 2, "name": "name_2"},
+			{"id": 3, "name": "name_3"},
+		}).Insert()
+		t.Assert(err, nil)
+		n, _ := r.RowsAffected()
+		t.Assert(n, 3)
+
+		// Soft deleting with flag.
+		r, err = db.Table(table).Delete("id", g.Slice{1, 2})
+		t.Assert(err, nil)
+		n, _ = r.RowsAffected()
+		t.Assert(n, 2)
+		one, err := db.Table(table).Unscoped().FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one["delete_at"].Int(), 1)
+
+		count, err := db.Table(table).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 1)
+		count, err = db.Table(table).OnlyTrashed().Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+		all, err := db.Table(table).OnlyTrashed().Order("id asc").All()
+		t.Assert(err, nil)
+		t.Assert(len(all), 2)
+		t.Assert(all[0]["id"], 1)
+		t.Assert(all[1]["id"], 2)
+
+		// Restore.
+		r, err = db.Table(table).Restore("id", 1)
+		t.Assert(err, nil)
+		n, _ = r.RowsAffected()
+		t.Assert(n, 1)
+		one, err = db.Table(table).FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one["delete_at"].Int(), 0)
+		count, err = db.Table(table).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 2)
+	})
+}
+
+func Test_SoftDelete_Strategy_Unix(t *testing.T) {
+	table := "time_test_table_" + gtime.TimestampNanoStr()
+	if _, err := db.Exec(fmt.Sprintf(`
+CREATE TABLE %s (
+  id        int(11) NOT NULL,
+  name      varchar(45) DEFAULT NULL,
+  delete_at int(11) DEFAULT NULL,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+    `, table)); err != nil {
+		gtest.Error(err)
+	}
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Table(table).Data(g.Map{"id": 1, "name": "name_1"}).Insert()
+		t.Assert(err, nil)
+		one, err := db.Table(table).FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one["delete_at"].Int(), 0)
+
+		// Soft deleting with unix timestamp.
+		_, err = db.Table(table).Delete("id", 1)
+		t.Assert(err, nil)
+		one, err = db.Table(table).FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one.IsEmpty(), true)
+		one, err = db.Table(table).OnlyTrashed().FindOne(1)
+		t.Assert(err, nil)
+		t.AssertGE(one["delete_at"].Int64(), gtime.Timestamp()-2)
+		t.AssertLE(one["delete_at"].Int64(), gtime.Timestamp())
+
+		_, err = db.Table(table).OnlyTrashed().Restore()
+		t.Assert(err, nil)
+		one, err = db.Table(table).FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one["delete_at"].Int(), 0)
+	})
+}
+
+func Test_SoftDelete_Strategy_Save(t *testing.T) {
+	table := "time_test_table_" + gtime.TimestampNanoStr()
+	if _, err := db.Exec(fmt.Sprintf(`
+CREATE TABLE %s (
+  id        int(11) NOT NULL,
+  name      varchar(45) DEFAULT NULL,
+  delete_at int(11) DEFAULT NULL,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+    `, table)); err != nil {
+		gtest.Error(err)
+	}
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Table(table).Data(g.Map{"id": 1, "name": "name_1"}).Save()
+		t.Assert(err, nil)
+		one, err := db.Table(table).FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one["delete_at"].IsNil(), false)
+		t.Assert(one["delete_at"].Int(), 0)
+
+		// Saving does not restore the soft deleted record.
+		_, err = db.Table(table).Delete("id", 1)
+		t.Assert(err, nil)
+		_, err = db.Table(table).Data(g.Map{"id": 1, "name": "name_100"}).Save()
+		t.Assert(err, nil)
+		one, err = db.Table(table).FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one.IsEmpty(), true)
+		one, err = db.Table(table).OnlyTrashed().FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one["name"], "name_100")
+	})
+}
+
+func Test_SoftDelete_Strategy_Config(t *testing.T) {
+	table := "time_test_table_" + gtime.TimestampNanoStr()
+	if _, err := db.Exec(fmt.Sprintf(`
+CREATE TABLE %s (
+  id        int(11) NOT NULL,
+  name      varchar(45) DEFAULT NULL,
+  delete_at bigint(20) NOT NULL DEFAULT 0,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+    `, table)); err != nil {
+		gtest.Error(err)
+	}
+	defer dropTable(table)
+
+	db.GetConfig().SoftDeleteStrategy = map[string]string{table: gdb.SoftDeleteFlag}
+	defer func() {
+		db.GetConfig().SoftDeleteStrategy = nil
+	}()
+
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Table(table).Data(g.List{
+			{"id": 1, "name": "name_1"},
+			{"id": 2, "name": "name_2"},
+		}).Insert()
+		t.Assert(err, nil)
+
+		// The configured flag strategy takes place of the unix milliseconds one of bigint.
+		_, err = db.Table(table).Delete("id", 1)
+		t.Assert(err, nil)
+		one, err := db.Table(table).Unscoped().FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one["delete_at"].Int(), 1)
+		count, err := db.Table(table).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 1)
+	})
+}
+
+func Test_SoftDelete_Strategy_TimePrecision(t *testing.T) {
+	table := "time_test_table_" + gtime.TimestampNanoStr()
+	if _, err := db.Exec(fmt.Sprintf(`
+CREATE TABLE %s (
+  id        int(11) NOT NULL,
+  name      varchar(45) DEFAULT NULL,
+  update_at bigint(20) DEFAULT NULL,
+  delete_at bigint(20) NOT NULL DEFAULT 0,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+    `, table)); err != nil {
+		gtest.Error(err)
+	}
+	defer dropTable(table)
+
+	db.GetConfig().TimePrecision = gdb.TimePrecisionSecond
+	defer func() {
+		db.GetConfig().TimePrecision = ""
+	}()
+
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Table(table).Data(g.Map{"id": 1, "name": "name_1"}).Insert()
+		t.Assert(err, nil)
+		_, err = db.Table(table).Delete("id", 1)
+		t.Assert(err, nil)
+		one, err := db.Table(table).OnlyTrashed().FindOne(1)
+		t.Assert(err, nil)
+		t.AssertLE(one["update_at"].Int64(), gtime.Timestamp())
+		t.AssertGE(one["delete_at"].Int64(), gtime.Timestamp()-2)
+		t.AssertLE(one["delete_at"].Int64(), gtime.Timestamp())
+	})
+}
+
+func Test_SoftCreatedUpdatedTime_FieldType(t *testing.T) {
+	table := "time_test_table_" + gtime.TimestampNanoStr()
+	if _, err := db.Exec(fmt.Sprintf(`
+CREATE TABLE %s (
+  id         int(11) NOT NULL,
+  name       varchar(45) DEFAULT NULL,
+  created_at int(11) DEFAULT NULL,
+  updated_at bigint(20) DEFAULT NULL,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+    `, table)); err != nil {
+		gtest.Error(err)
+	}
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		// Insert with unix seconds and milliseconds.
+		_, err := db.Table(table).Data(g.Map{"id": 1, "name": "name_1"}).Insert()
+		t.Assert(err, nil)
+		one, err := db.Table(table).FindOne(1)
+		t.Assert(err, nil)
+		t.AssertGE(one["created_at"].Int64(), gtime.Timestamp()-2)
+		t.AssertLE(one["created_at"].Int64(), gtime.Timestamp())
+		t.AssertGE(one["updated_at"].Int64(), gtime.TimestampMilli()-2000)
+		t.AssertLE(one["updated_at"].Int64(), gtime.TimestampMilli())
+
+		time.Sleep(time.Second)
+
+		// Update.
+		_, err = db.Table(table).Data(g.Map{"name": "name_100"}).Where("id", 1).Update()
+		t.Assert(err, nil)
+		one2, err := db.Table(table).FindOne(1)
+		t.Assert(err, nil)
+		t.Assert(one2["created_at"], one["created_at"])
+		t.AssertGT(one2["updated_at"].Int64(), one["updated_at"].Int64())
+		t.AssertGE(one2["updated_at"].Int64(), gtime.TimestampMilli()-2000)
+	})
+}
+
+func Test_SoftCreatedUpdatedTime_Date(t *testing.T) {
+	table := "time_test_table_" + gtime.TimestampNanoStr()
+	if _, err := db.Exec(fmt.Sprintf(`
+CREATE TABLE %s (
+  id         int(11) NOT NULL,
+  name       varchar(45) DEFAULT NULL,
+  created_at date DEFAULT NULL,
+  updated_at datetime(3) DEFAULT NULL,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+    `, table)); err != nil {
+		gtest.Error(err)
+	}
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		_, err := db.Table(table).Data(g.Map{"id": 1, "name": "name_1"}).Insert()
+		t.Assert(err, nil)
+		value, err := db.Table(table).Fields("DATE_FORMAT(created_at, '%Y-%m-%d')").Where("id", 1).Value()
+		t.Assert(err, nil)
+		t.Assert(value.String(), gtime.Now().Format("Y-m-d"))
+		value, err = db.Table(table).Fields("DATE_FORMAT(updated_at, '%Y-%m-%d')").Where("id", 1).Value()
+		t.Assert(err, nil)
+		t.Assert(value.String(), gtime.Now().Format("Y-m-d"))
+	})
+}
+
 func Test_CreateUpdateTime_Struct(t *testing.T) {
 	table := "time_test_table"
 	if _, err := db.Exec(fmt.Sprintf(`
@@ -7,10 +7,13 @@
 package gdb_test
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"github.com/gogf/gf/database/gdb"
 	"github.com/gogf/gf/errors/gerror"
 	"testing"
+	"time"
 
 	"github.com/gogf/gf/frame/g"
 	"github.com/gogf/gf/os/gtime"
@@ -789,3 +792,226 @@ func Test_Transaction_Panic(t *testing.T) {
 		}
 	})
 }
+
+func Test_Transaction_Nested(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		err := db.Transaction(func(tx *gdb.TX) error {
+			if _, err := tx.Update(table, "nickname='NAME_1'", "id", 1); err != nil {
+				return err
+			}
+			// The inner transaction fails and rolls back to its save point only.
+			err := tx.Transaction(func(tx *gdb.TX) error {
+				if _, err := tx.Update(table, "nickname='NAME_2'", "id", 2); err != nil {
+					return err
+				}
+				return gerror.New("error")
+			})
+			t.AssertNE(err, nil)
+			// The inner transaction succeeds.
+			return tx.Transaction(func(tx *gdb.TX) error {
+				_, err := tx.Update(table, "nickname='NAME_3'", "id", 3)
+				return err
+			})
+		})
+		t.Assert(err, nil)
+
+		array, err := db.Table(table).Fields("nickname").Where("id", g.Slice{1, 2, 3}).Order("id asc").Array()
+		t.Assert(err, nil)
+		t.Assert(array, g.Slice{"NAME_1", "name_2", "NAME_3"})
+	})
+}
+
+func Test_Transaction_Nested_RollbackError(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		innerError := gerror.New("inner error")
+		err := db.Transaction(func(tx *gdb.TX) error {
+			// The save point is released in the inner transaction, so rolling back to it fails,
+			// the original error is still kept as the cause.
+			err := tx.Transaction(func(tx *gdb.TX) error {
+				if err := tx.ReleaseSavePoint("gf_save_point_1"); err != nil {
+					return err
+				}
+				return innerError
+			})
+			t.AssertNE(err, innerError)
+			t.Assert(gerror.Cause(err), innerError)
+
+			err = tx.Transaction(func(tx *gdb.TX) error {
+				if err := tx.ReleaseSavePoint("gf_save_point_1"); err != nil {
+					return err
+				}
+				panic(innerError)
+			})
+			t.Assert(gerror.Cause(err), innerError)
+			return nil
+		})
+		t.Assert(err, nil)
+	})
+}
+
+func Test_TX_SavePoint(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		tx, err := db.Begin()
+		t.Assert(err, nil)
+
+		_, err = tx.Update(table, "nickname='NAME_1'", "id", 1)
+		t.Assert(err, nil)
+		t.Assert(tx.SavePoint("MyPoint"), nil)
+		_, err = tx.Update(table, "nickname='NAME_2'", "id", 2)
+		t.Assert(err, nil)
+		t.Assert(tx.RollbackTo("MyPoint"), nil)
+		t.Assert(tx.ReleaseSavePoint("MyPoint"), nil)
+		t.Assert(tx.Commit(), nil)
+
+		array, err := db.Table(table).Fields("nickname").Where("id", g.Slice{1, 2}).Order("id asc").Array()
+		t.Assert(err, nil)
+		t.Assert(array, g.Slice{"NAME_1", "name_2"})
+	})
+}
+
+func Test_Transaction_WithOptions(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		err := db.TransactionWithOptions(context.Background(), gdb.TxOptions{
+			Isolation: sql.LevelRepeatableRead,
+			ReadOnly:  true,
+			Timeout:   10 * time.Second,
+		}, func(tx *gdb.TX) error {
+			count, err := tx.Model(table).Count()
+			t.Assert(err, nil)
+			t.Assert(count, SIZE)
+			// Writing is not allowed in read-only transaction.
+			_, err = tx.Update(table, "nickname='NAME_1'", "id", 1)
+			t.AssertNE(err, nil)
+			return nil
+		})
+		t.Assert(err, nil)
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		tx, err := db.BeginWithOptions(context.Background(), gdb.TxOptions{
+			Isolation: sql.LevelReadCommitted,
+			Timeout:   10 * time.Second,
+		})
+		t.Assert(err, nil)
+		_, err = tx.Update(table, "nickname='NAME_1'", "id", 1)
+		t.Assert(err, nil)
+		t.Assert(tx.Commit(), nil)
+
+		value, err := db.Model(table).Fields("nickname").Where("id", 1).Value()
+		t.Assert(err, nil)
+		t.Assert(value.String(), "NAME_1")
+	})
+}
+
+func Test_Transaction_Retry(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		var (
+			times      = 0
+			retryError = gerror.New("retry")
+		)
+		err := db.TransactionWithOptions(context.Background(), gdb.TxOptions{
+			Retry: gdb.TxRetryPolicy{
+				MaxAttempts: 3,
+				Interval:    time.Millisecond,
+				Retryable: func(err error) bool {
+					return err == retryError
+				},
+			},
+		}, func(tx *gdb.TX) error {
+			times++
+			if _, err := tx.Update(table, "nickname='NAME_1'", "id", 1); err != nil {
+				return err
+			}
+			if times < 3 {
+				return retryError
+			}
+			return nil
+		})
+		t.Assert(err, nil)
+		t.Assert(times, 3)
+
+		value, err := db.Model(table).Fields("nickname").Where("id", 1).Value()
+		t.Assert(err, nil)
+		t.Assert(value.String(), "NAME_1")
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		times := 0
+		err := db.TransactionWithOptions(context.Background(), gdb.TxOptions{
+			Retry: gdb.TxRetryPolicy{MaxAttempts: 3},
+		}, func(tx *gdb.TX) error {
+			times++
+			return gerror.New("not retryable")
+		})
+		t.AssertNE(err, nil)
+		t.Assert(times, 1)
+	})
+}
+
+func Test_Transaction_Callbacks(t *testing.T) {
+	table := createInitTable()
+	defer dropTable(table)
+
+	gtest.C(t, func(t *gtest.T) {
+		array := make([]string, 0)
+		err := db.Transaction(func(tx *gdb.TX) error {
+			tx.OnCommit(func() {
+				array = append(array, "commit1")
+			})
+			tx.OnCommit(func() {
+				panic("error")
+			})
+			tx.OnCommit(func() {
+				array = append(array, "commit2")
+			})
+			tx.OnRollback(func() {
+				array = append(array, "rollback")
+			})
+			// Callbacks of the rolled back nested transaction are discarded.
+			err := tx.Transaction(func(tx *gdb.TX) error {
+				tx.OnCommit(func() {
+					array = append(array, "nested_commit")
+				})
+				tx.OnRollback(func() {
+					array = append(array, "nested_rollback")
+				})
+				return gerror.New("error")
+			})
+			t.AssertNE(err, nil)
+			t.Assert(array, []string{"nested_rollback"})
+			return nil
+		})
+		t.Assert(err, nil)
+		t.Assert(array, []string{"nested_rollback", "commit1", "commit2"})
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		array := make([]string, 0)
+		err := db.Transaction(func(tx *gdb.TX) error {
+			tx.OnCommit(func() {
+				array = append(array, "commit")
+			})
+			tx.OnRollback(func() {
+				array = append(array, "rollback")
+			})
+			return gerror.New("error")
+		})
+		t.AssertNE(err, nil)
+		t.Assert(array, []string{"rollback"})
+	})
+}
@@ -0,0 +1,270 @@
+// Copyright GoFrame Author(https://goframe.org). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://github.com/gogf/gf.
+
+package gdb_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gogf/gf/frame/g"
+	"github.com/gogf/gf/os/gtime"
+	"github.com/gogf/gf/test/gtest"
+)
+
+var (
+	tableWithUser        = "user_" + gtime.TimestampMicroStr()
+	tableWithUserDetail  = "user_detail_" + gtime.TimestampMicroStr()
+	tableWithUserScores  = "user_scores_" + gtime.TimestampMicroStr()
+	tableWithUserAddress = "user_address_" + gtime.TimestampMicroStr()
+)
+
+type WithUserAddress struct {
+	Id      int    `orm:"id"`
+	Did     int    `orm:"did"`
+	Address string `orm:"address"`
+}
+
+func (WithUserAddress) TableName() string {
+	return tableWithUserAddress
+}
+
+type WithUserDetail struct {
+	Id        int                `orm:"id"`
+	Uid       int                `orm:"uid"`
+	Phone     string             `orm:"phone"`
+	Addresses []*WithUserAddress `orm:"with:did=id"`
+}
+
+func (WithUserDetail) TableName() string {
+	return tableWithUserDetail
+}
+
+type WithUserScores struct {
+	Id    int `orm:"id"`
+	Uid   int `orm:"uid"`
+	Score int `orm:"score"`
+}
+
+func (WithUserScores) TableName() string {
+	return tableWithUserScores
+}
+
+type WithUser struct {
+	Id     int              `orm:"id"`
+	Name   string           `orm:"name"`
+	Detail *WithUserDetail  `orm:"with:uid=id"`
+	Scores []WithUserScores `orm:"with:uid=id"`
+}
+
+func createWithTables(t *testing.T) {
+	tables := []string{tableWithUser, tableWithUserDetail, tableWithUserScores, tableWithUserAddress}
+	schemas := []string{
+		`CREATE TABLE %s (id int(10) unsigned NOT NULL, name varchar(45) NOT NULL, PRIMARY KEY (id))`,
+		`CREATE TABLE %s (id int(10) unsigned NOT NULL, uid int(10) unsigned NOT NULL, phone varchar(45) NOT NULL, PRIMARY KEY (id))`,
+		`CREATE TABLE %s (id int(10) unsigned NOT NULL AUTO_INCREMENT, uid int(10) unsigned NOT NULL, score int(10) unsigned NOT NULL, PRIMARY KEY (id))`,
+		`CREATE TABLE %s (id int(10) unsigned NOT NULL AUTO_INCREMENT, did int(10) unsigned NOT NULL, address varchar(45) NOT NULL, PRIMARY KEY (id))`,
+	}
+	for i, table := range tables {
+		if _, err := db.Exec(fmt.Sprintf(schemas[i], table)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i <= 3; i++ {
+		_, err := db.Insert(tableWithUser, g.Map{"id": i, "name": fmt.Sprintf(`name_%d`, i)})
+		gtest.Assert(err, nil)
+		_, err = db.Insert(tableWithUserDetail, g.Map{"id": i * 10, "uid": i, "phone": fmt.Sprintf(`phone_%d`, i)})
+		gtest.Assert(err, nil)
+		for j := 1; j <= 2; j++ {
+			_, err = db.Insert(tableWithUserScores, g.Map{"uid": i, "score": i*10 + j})
+			gtest.Assert(err, nil)
+			_, err = db.Insert(tableWithUserAddress, g.Map{"did": i * 10, "address": fmt.Sprintf(`address_%d_%d`, i, j)})
+			gtest.Assert(err, nil)
+		}
+	}
+}
+
+func dropWithTables() {
+	dropTable(tableWithUser)
+	dropTable(tableWithUserDetail)
+	dropTable(tableWithUserScores)
+	dropTable(tableWithUserAddress)
+}
+
+func Test_Model_With(t *testing.T) {
+	createWithTables(t)
+	defer dropWithTables()
+
+	gtest.C(t, func(t *gtest.T) {
+		var user *WithUser
+		err := db.Model(tableWithUser).With(WithUserDetail{}).Where("id", 2).Scan(&user)
+		t.Assert(err, nil)
+		t.Assert(user.Name, "name_2")
+		t.AssertNE(user.Detail, nil)
+		t.Assert(user.Detail.Phone, "phone_2")
+		t.Assert(len(user.Detail.Addresses), 0)
+		t.Assert(len(user.Scores), 0)
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		var users []*WithUser
+		err := db.Model(tableWithUser).With(WithUser{}.Scores).Order("id asc").Scan(&users)
+		t.Assert(err, nil)
+		t.Assert(len(users), 3)
+		t.Assert(users[0].Detail, nil)
+		t.Assert(len(users[0].Scores), 2)
+		t.Assert(users[0].Scores[0].Score, 11)
+		t.Assert(users[2].Scores[1].Score, 32)
+	})
+}
+
+func Test_Model_WithAll(t *testing.T) {
+	createWithTables(t)
+	defer dropWithTables()
+
+	gtest.C(t, func(t *gtest.T) {
+		var users []WithUser
+		err := db.Model(tableWithUser).WithAll().Order("id asc").Scan(&users)
+		t.Assert(err, nil)
+		t.Assert(len(users), 3)
+		for i, user := range users {
+			t.Assert(user.Id, i+1)
+			t.Assert(user.Detail.Uid, i+1)
+			t.Assert(len(user.Scores), 2)
+			t.Assert(len(user.Detail.Addresses), 2)
+			t.Assert(user.Detail.Addresses[0].Address, fmt.Sprintf(`address_%d_1`, i+1))
+		}
+	})
+}
+
+type WithCycleUserDetail struct {
+	Id    int            `orm:"id"`
+	Uid   int            `orm:"uid"`
+	Phone string         `orm:"phone"`
+	User  *WithCycleUser `orm:"with:id=uid"`
+}
+
+func (WithCycleUserDetail) TableName() string {
+	return tableWithUserDetail
+}
+
+type WithCycleUser struct {
+	Id     int                  `orm:"id"`
+	Name   string               `orm:"name"`
+	Detail *WithCycleUserDetail `orm:"with:uid=id"`
+}
+
+func (WithCycleUser) TableName() string {
+	return tableWithUser
+}
+
+func Test_Model_WithAll_Cycle(t *testing.T) {
+	createWithTables(t)
+	defer dropWithTables()
+
+	gtest.C(t, func(t *gtest.T) {
+		var user *WithCycleUser
+		err := db.Model(tableWithUser).WithAll().Where("id", 2).Scan(&user)
+		t.Assert(err, nil)
+		t.Assert(user.Name, "name_2")
+		t.AssertNE(user.Detail, nil)
+		t.Assert(user.Detail.Phone, "phone_2")
+		t.Assert(user.Detail.User, nil)
+	})
+
+	gtest.C(t, func(t *gtest.T) {
+		var details []*WithCycleUserDetail
+		err := db.Model(tableWithUserDetail).WithAll().Order("id asc").Scan(&details)
+		t.Assert(err, nil)
+		t.Assert(len(details), 3)
+		t.AssertNE(details[0].User, nil)
+		t.Assert(details[0].User.Name, "name_1")
+		t.Assert(details[0].User.Detail, nil)
+	})
+}
+
+var (
+	tableWithRole     = "role_" + gtime.TimestampMicroStr()
+	tableWithUserRole = "user_role_" + gtime.TimestampMicroStr()
+)
+
+type WithRole struct {
+	Id   int    `orm:"id"`
+	Name string `orm:"name"`
+}
+
+func (WithRole) TableName() string {
+	return tableWithRole
+}
+
+type WithUserRoles struct {
+	Id    int         `orm:"id"`
+	Name  string      `orm:"name"`
+	Roles []*WithRole `orm:"with:id=role_id, pivot:user_role, pivot_key:uid=id"`
+}
+
+func Test_Model_With_Pivot(t *testing.T) {
+	createWithTables(t)
+	defer dropWithTables()
+
+	if _, err := db.Exec(fmt.Sprintf(
+		`CREATE TABLE %s (id int(10) unsigned NOT NULL, name varchar(45) NOT NULL, PRIMARY KEY (id))`,
+		tableWithRole,
+	)); err != nil {
+		t.Fatal(err)
+	}
+	defer dropTable(tableWithRole)
+	if _, err := db.Exec(fmt.Sprintf(
+		`CREATE TABLE %s (uid int(10) unsigned NOT NULL, role_id int(10) unsigned NOT NULL, PRIMARY KEY (uid, role_id))`,
+		"user_role",
+	)); err != nil {
+		t.Fatal(err)
+	}
+	defer dropTable("user_role")
+	for i := 1; i <= 3; i++ {
+		_, err := db.Insert(tableWithRole, g.Map{"id": i, "name": fmt.Sprintf(`role_%d`, i)})
+		gtest.Assert(err, nil)
+	}
+
+	gtest.C(t, func(t *gtest.T) {
+		var user *WithUserRoles
+		err := db.Model(tableWithUser).Where("id", 1).Scan(&user)
+		t.Assert(err, nil)
+
+		// Attach.
+		err = db.Model(tableWithUser).Attach(user, "Roles", 1, 2)
+		t.Assert(err, nil)
+		err = db.Model(tableWithUser).Attach(user, "Roles", 2, 3)
+		t.Assert(err, nil)
+		count, err := db.Model("user_role").Where("uid", 1).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 3)
+
+		// Detach.
+		err = db.Model(tableWithUser).Detach(user, "Roles", 1)
+		t.Assert(err, nil)
+
+		var users []*WithUserRoles
+		err = db.Model(tableWithUser).WithAll().Order("id asc").Scan(&users)
+		t.Assert(err, nil)
+		t.Assert(len(users), 3)
+		t.Assert(len(users[0].Roles), 2)
+		t.Assert(len(users[1].Roles), 0)
+
+		// Sync.
+		err = db.Model(tableWithUser).Sync(user, "Roles", 1, 3)
+		t.Assert(err, nil)
+		array, err := db.Model("user_role").Fields("role_id").Where("uid", 1).Order("role_id asc").Array()
+		t.Assert(err, nil)
+		t.Assert(array, g.Slice{1, 3})
+
+		err = db.Model(tableWithUser).Detach(user, "Roles")
+		t.Assert(err, nil)
+		count, err = db.Model("user_role").Where("uid", 1).Count()
+		t.Assert(err, nil)
+		t.Assert(count, 0)
+	})
+}